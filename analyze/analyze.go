@@ -0,0 +1,336 @@
+// Package analyze looks for synchronized clients in request timestamps
+// It detects the period clients call at, how tightly their calls cluster at the same phase of the period,
+// and estimates the effective jitter they use, to find the callers that need more jitter
+package analyze
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Sample is the time of a request by a client
+type Sample struct {
+	Client string    // Client making the request, empty when unknown
+	At     time.Time // Time of the request
+}
+
+// Config configures an analysis
+type Config struct {
+	Period     time.Duration   // Period to analyze the phases at, detected when zero
+	Candidates []time.Duration // Periods considered when detecting the period of all clients, DefaultCandidates when empty
+	Coverage   float64         // Fraction of the events the spread has to cover, 0.9 when zero
+}
+
+// DefaultCandidates are the periods commonly used for polling and cron jobs
+var DefaultCandidates = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	15 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// minClusterZ is the Rayleigh statistic needed for the phases to count as clustered, the chance of reaching it with
+// uniformly random phases is about one in a million
+const minClusterZ = 13.8
+
+// minClustering is the mean resultant length needed for the phases to count as clustered, so large samples with a
+// statistically significant but weak preference don't get flagged
+const minClustering = 0.25
+
+// Report is the result of analyzing the requests of a client, or of all clients
+type Report struct {
+	Client string    // Client the report is about, empty for all clients
+	Count  int       // Number of requests
+	First  time.Time // Time of the first request
+	Last   time.Time // Time of the last request
+
+	Period time.Duration // Period of the requests, zero if they aren't periodic
+	Jitter time.Duration // Estimated max jitter added to the period, from the spread of the intervals between requests of a client
+
+	Phase      time.Duration // Mean offset of the requests within the period
+	Clustering float64       // Mean resultant length of the phases, from 0 for uniformly spread phases to 1 for identical ones
+	Clustered  bool          // Whether the phases are significantly clustered
+	Spread     time.Duration // Width of the smallest window within the period holding the covered fraction of the requests
+}
+
+// JitterRatio returns the jitter as a fraction of the period, or zero if the requests aren't periodic
+func (r Report) JitterRatio() float64 {
+	if r.Period == 0 {
+		return 0
+	}
+
+	return float64(r.Jitter) / float64(r.Period)
+}
+
+// Analyze analyzes the requests of a single client
+// Unless configured, the period is the median interval between requests, as long as the intervals don't vary by more than it
+func Analyze(times []time.Time, cfg Config) Report {
+	cfg = withDefaults(cfg)
+
+	sorted := sortedTimes(times)
+	report := newReport(sorted)
+	if len(sorted) < 3 {
+		return report
+	}
+
+	intervals := make([]time.Duration, len(sorted)-1)
+	for i := range intervals {
+		intervals[i] = sorted[i+1].Sub(sorted[i])
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i] < intervals[j]
+	})
+
+	// Intervals of period plus a uniform jitter in [0, j) spread over 90% of j between the 5th and 95th percentile
+	jitter := time.Duration(float64(percentile(intervals, 0.95)-percentile(intervals, 0.05)) / 0.9)
+	period := cfg.Period
+	if period == 0 {
+		period = percentile(intervals, 0.5)
+		if period <= 0 || jitter > period {
+			return report // Too irregular to be periodic
+		}
+	}
+
+	report.Period = period
+	report.Jitter = jitter
+	report.phases(sorted, period, cfg.Coverage)
+
+	return report
+}
+
+// AnalyzeClients analyzes the requests of every client, and of all of them together
+// The reports of the clients are sorted by client
+// Unless configured, the period of all clients is the longest of the candidate periods and the periods of the clients
+// at which the phases cluster nearly as significantly as at the best one, so a fleet calling at the top of every minute
+// is reported with a period of a minute rather than a second
+func AnalyzeClients(samples []Sample, cfg Config) (Report, []Report) {
+	cfg = withDefaults(cfg)
+
+	byClient := make(map[string][]time.Time)
+	all := make([]time.Time, 0, len(samples))
+	for _, sample := range samples {
+		byClient[sample.Client] = append(byClient[sample.Client], sample.At)
+		all = append(all, sample.At)
+	}
+
+	clients := make([]Report, 0, len(byClient))
+	for client, times := range byClient {
+		report := Analyze(times, cfg)
+		report.Client = client
+		clients = append(clients, report)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Client < clients[j].Client
+	})
+
+	all = sortedTimes(all)
+	overall := newReport(all)
+	if len(all) < 3 {
+		return overall, clients
+	}
+
+	period := cfg.Period
+	if period == 0 {
+		candidates := append([]time.Duration(nil), cfg.Candidates...)
+		for _, client := range clients {
+			if client.Period > 0 {
+				candidates = append(candidates, roundPeriod(client.Period))
+			}
+		}
+
+		period = detectPeriod(all, candidates)
+	}
+
+	if period > 0 {
+		overall.Period = period
+		overall.phases(all, period, cfg.Coverage)
+	}
+
+	return overall, clients
+}
+
+// detectPeriod returns the longest candidate period the phases cluster at nearly as significantly as at the best one,
+// or zero if they don't cluster at any of them
+// Candidates have to fit in the sampled time at least twice, and be longer than the resolution of the timestamps,
+// as timestamps with a resolution of a second trivially all have the same phase at a period of a second
+func detectPeriod(times []time.Time, candidates []time.Duration) time.Duration {
+	span := times[len(times)-1].Sub(times[0])
+	resolution := resolution(times)
+
+	z := make(map[time.Duration]float64, len(candidates))
+	best := 0.0
+	for _, candidate := range candidates {
+		if candidate <= resolution || 2*candidate > span {
+			continue
+		}
+
+		r, _ := meanResultant(times, candidate)
+		z[candidate] = float64(len(times)) * r * r
+		if r >= minClustering {
+			best = math.Max(best, z[candidate])
+		}
+	}
+
+	if best < minClusterZ {
+		return 0
+	}
+
+	var period time.Duration
+	for candidate, value := range z {
+		if value >= 0.9*best && candidate > period {
+			period = candidate
+		}
+	}
+
+	return period
+}
+
+// resolution returns the coarsest of a second, a millisecond, a microsecond and a nanosecond all the times are a multiple of
+func resolution(times []time.Time) time.Duration {
+	for resolution := time.Second; resolution > time.Nanosecond; resolution /= 1000 {
+		multiple := true
+		for _, t := range times {
+			if t.UnixNano()%int64(resolution) != 0 {
+				multiple = false
+				break
+			}
+		}
+
+		if multiple {
+			return resolution
+		}
+	}
+
+	return time.Nanosecond
+}
+
+// roundPeriod rounds a period estimated from the intervals between requests, which vary by the jitter,
+// to the precision periods are usually configured with
+func roundPeriod(period time.Duration) time.Duration {
+	if period >= 10*time.Second {
+		return period.Round(time.Second)
+	}
+
+	return period.Round(10 * time.Millisecond)
+}
+
+// phases fills in the phase statistics of the times at the period
+func (r *Report) phases(times []time.Time, period time.Duration, coverage float64) {
+	clustering, phase := meanResultant(times, period)
+
+	r.Clustering = clustering
+	r.Phase = time.Duration(phase * float64(period))
+	r.Clustered = clustering >= minClustering && float64(len(times))*clustering*clustering >= minClusterZ
+	r.Spread = time.Duration(smallestArc(times, period, coverage) * float64(period))
+}
+
+// meanResultant returns the length of the mean of the phases of the times at the period as unit vectors,
+// and the direction of that mean as a fraction of the period
+func meanResultant(times []time.Time, period time.Duration) (float64, float64) {
+	var x, y float64
+	for _, t := range times {
+		angle := 2 * math.Pi * phase(t, period)
+		x += math.Cos(angle)
+		y += math.Sin(angle)
+	}
+
+	n := float64(len(times))
+	direction := math.Atan2(y, x) / (2 * math.Pi)
+	if direction < 0 {
+		direction++
+	}
+
+	return math.Hypot(x, y) / n, direction
+}
+
+// smallestArc returns the length of the smallest arc of the circle of phases holding the covered fraction of the times,
+// as a fraction of the period
+func smallestArc(times []time.Time, period time.Duration, coverage float64) float64 {
+	n := len(times)
+	k := int(math.Ceil(coverage * float64(n)))
+	if k <= 1 {
+		return 0
+	}
+
+	phases := make([]float64, n, 2*n)
+	for i, t := range times {
+		phases[i] = phase(t, period)
+	}
+
+	sort.Float64s(phases)
+
+	// Unroll the circle so arcs can wrap around
+	for i := 0; i < n; i++ {
+		phases = append(phases, phases[i]+1)
+	}
+
+	arc := 1.0
+	for i := 0; i < n; i++ {
+		arc = math.Min(arc, phases[i+k-1]-phases[i])
+	}
+
+	return arc
+}
+
+// phase returns the offset of t within the period counted from the Unix epoch, as a fraction of the period
+func phase(t time.Time, period time.Duration) float64 {
+	offset := t.UnixNano() % int64(period)
+	if offset < 0 {
+		offset += int64(period)
+	}
+
+	return float64(offset) / float64(period)
+}
+
+// percentile returns the value at the fraction p of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	return sorted[int(math.Round(p*float64(len(sorted)-1)))]
+}
+
+func newReport(sorted []time.Time) Report {
+	report := Report{
+		Count: len(sorted),
+	}
+
+	if len(sorted) > 0 {
+		report.First = sorted[0]
+		report.Last = sorted[len(sorted)-1]
+	}
+
+	return report
+}
+
+func sortedTimes(times []time.Time) []time.Time {
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+
+	return sorted
+}
+
+func withDefaults(cfg Config) Config {
+	if len(cfg.Candidates) == 0 {
+		cfg.Candidates = DefaultCandidates
+	}
+
+	if cfg.Coverage <= 0 || cfg.Coverage > 1 {
+		cfg.Coverage = 0.9
+	}
+
+	return cfg
+}
@@ -0,0 +1,143 @@
+package analyze_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/analyze"
+)
+
+var start = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+// synchronized returns samples of clients calling at the top of every minute with up to a second of jitter
+func synchronized(source *jitter.Source, clients int, minutes int) []analyze.Sample {
+	var samples []analyze.Sample
+	for c := 0; c < clients; c++ {
+		for m := 0; m < minutes; m++ {
+			samples = append(samples, analyze.Sample{
+				Client: fmt.Sprintf("sync-%d", c),
+				At:     start.Add(source.Duration(time.Duration(m)*time.Minute, time.Second)),
+			})
+		}
+	}
+
+	return samples
+}
+
+// jittered returns samples of clients calling every minute with up to 30 seconds of jitter, like a jitter.Ticker
+func jittered(source *jitter.Source, clients int, calls int) []analyze.Sample {
+	var samples []analyze.Sample
+	for c := 0; c < clients; c++ {
+		at := start.Add(source.Duration(0, time.Minute))
+		for i := 0; i < calls; i++ {
+			at = at.Add(source.Duration(time.Minute, 30*time.Second))
+			samples = append(samples, analyze.Sample{
+				Client: fmt.Sprintf("jittered-%d", c),
+				At:     at,
+			})
+		}
+	}
+
+	return samples
+}
+
+func TestSynchronized(t *testing.T) {
+	overall, clients := analyze.AnalyzeClients(synchronized(jitter.NewSource(1), 20, 60), analyze.Config{})
+
+	if overall.Count != 1200 || overall.Period != time.Minute || !overall.Clustered {
+		t.Fatalf("expected clustering at a minute, got %+v", overall)
+	}
+
+	if overall.Spread > time.Second || overall.Phase > time.Second {
+		t.Errorf("expected the requests within the first second of the minute, got %v wide at %v", overall.Spread, overall.Phase)
+	}
+
+	if len(clients) != 20 {
+		t.Fatalf("expected 20 clients, got %d", len(clients))
+	}
+
+	for _, client := range clients {
+		if client.Period < 59*time.Second || client.Period > 61*time.Second || client.Jitter > 3*time.Second {
+			t.Errorf("expected a period of a minute with little jitter, got %+v", client)
+		}
+	}
+}
+
+func TestJittered(t *testing.T) {
+	overall, clients := analyze.AnalyzeClients(jittered(jitter.NewSource(1), 20, 60), analyze.Config{})
+
+	if overall.Clustered {
+		t.Errorf("expected no clustering, got %+v", overall)
+	}
+
+	for _, client := range clients {
+		if client.Period < 65*time.Second || client.Period > 85*time.Second {
+			t.Errorf("expected a period around 75 seconds, got %v", client.Period)
+		}
+
+		if client.Jitter < 20*time.Second || client.Jitter > 40*time.Second {
+			t.Errorf("expected jitter around 30 seconds, got %v", client.Jitter)
+		}
+
+		if ratio := client.JitterRatio(); ratio < 0.25 || ratio > 0.6 {
+			t.Errorf("unexpected jitter ratio: %v", ratio)
+		}
+	}
+}
+
+func TestConfiguredPeriod(t *testing.T) {
+	samples := synchronized(jitter.NewSource(1), 5, 60)
+
+	overall, _ := analyze.AnalyzeClients(samples, analyze.Config{Period: 7 * time.Second})
+	if overall.Period != 7*time.Second {
+		t.Errorf("expected the configured period, got %v", overall.Period)
+	}
+
+	// The minutes are spread evenly over a period of 7 seconds
+	if overall.Clustered {
+		t.Errorf("expected no clustering at an unrelated period, got %+v", overall)
+	}
+}
+
+func TestIrregular(t *testing.T) {
+	source := jitter.NewSource(1)
+
+	// Exponential intervals, as from independent random requests
+	var times []time.Time
+	at := start
+	for i := 0; i < 200; i++ {
+		at = at.Add(time.Duration(-float64(time.Minute) * logUniform(source)))
+		times = append(times, at)
+	}
+
+	if report := analyze.Analyze(times, analyze.Config{}); report.Period != 0 {
+		t.Errorf("expected no period, got %+v", report)
+	}
+
+	if report := analyze.Analyze(times[:2], analyze.Config{}); report.Count != 2 || report.Period != 0 {
+		t.Errorf("expected no period for two requests, got %+v", report)
+	}
+}
+
+// logUniform returns the natural logarithm of a uniform random number in (0, 1]
+func logUniform(source *jitter.Source) float64 {
+	return math.Log(1 - source.Float64())
+}
+
+func TestTimestampResolution(t *testing.T) {
+	// Timestamps with a resolution of a second all have the same phase at a period of a second
+	var samples []analyze.Sample
+	for m := 0; m < 30; m++ {
+		for s := 0; s < 3; s++ {
+			samples = append(samples, analyze.Sample{At: start.Add(time.Duration(m)*time.Minute + time.Duration(s)*time.Second)})
+		}
+	}
+
+	overall, _ := analyze.AnalyzeClients(samples, analyze.Config{})
+	if overall.Period != time.Minute || !overall.Clustered {
+		t.Errorf("expected clustering at a minute, got %+v", overall)
+	}
+}
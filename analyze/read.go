@@ -0,0 +1,132 @@
+package analyze
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format describes how to extract samples from the lines of a log
+type Format struct {
+	Pattern *regexp.Regexp // Pattern with a "time" and an optional "client" group, nil when every line is a timestamp
+	Layout  string         // Layout of the timestamps, detected when empty
+}
+
+// CommonLog is the format of access logs in the Common and Combined Log Formats, with the remote host as the client
+var CommonLog = Format{
+	Pattern: regexp.MustCompile(`^(?P<client>\S+) \S+ \S+ \[(?P<time>[^\]]+)\]`),
+	Layout:  "02/Jan/2006:15:04:05 -0700",
+}
+
+// layouts are the layouts tried when detecting timestamps, besides Unix timestamps in seconds
+var layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	CommonLog.Layout,
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// Read reads samples from a log, one per line
+// Blank lines and lines not matching the pattern are skipped, a timestamp that fails to parse is an error
+func Read(r io.Reader, format Format) ([]Sample, error) {
+	var timeIndex, clientIndex int
+	if format.Pattern != nil {
+		timeIndex = format.Pattern.SubexpIndex("time")
+		if timeIndex < 0 {
+			return nil, fmt.Errorf("pattern has no time group: %s", format.Pattern)
+		}
+
+		clientIndex = format.Pattern.SubexpIndex("client")
+	}
+
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20) // Access log lines can be long
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var sample Sample
+		if format.Pattern != nil {
+			match := format.Pattern.FindStringSubmatch(text)
+			if match == nil {
+				continue
+			}
+
+			text = match[timeIndex]
+			if clientIndex >= 0 {
+				sample.Client = match[clientIndex]
+			}
+		}
+
+		at, err := parseTime(text, format.Layout)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		sample.At = at
+		samples = append(samples, sample)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+// parseTime parses a timestamp in the layout, or in any of the known layouts when it's empty
+func parseTime(text string, layout string) (time.Time, error) {
+	if layout != "" {
+		t, err := time.Parse(layout, text)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp: %w", err)
+		}
+
+		return t, nil
+	}
+
+	if t, ok := parseUnix(text); ok {
+		return t, nil
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized timestamp: %q", text)
+}
+
+// parseUnix parses a Unix timestamp in seconds with up to nanosecond precision, such as 1791072000.123
+func parseUnix(text string) (time.Time, bool) {
+	whole, fraction, _ := strings.Cut(text, ".")
+	if len(fraction) > 9 {
+		fraction = fraction[:9]
+	}
+
+	seconds, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var nanos uint64
+	if fraction != "" {
+		if nanos, err = strconv.ParseUint(fraction+strings.Repeat("0", 9-len(fraction)), 10, 63); err != nil {
+			return time.Time{}, false
+		}
+	}
+
+	return time.Unix(int64(seconds), int64(nanos)), true
+}
@@ -0,0 +1,86 @@
+package analyze_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/analyze"
+)
+
+func TestRead(t *testing.T) {
+	samples, err := analyze.Read(strings.NewReader(`
+1791072000
+1791072000.25
+2026-10-16T00:00:01.5Z
+2026-10-16 00:00:02
+`), analyze.Format{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{
+		time.Unix(1791072000, 0),
+		time.Unix(1791072000, 250000000),
+		time.Date(2026, 10, 16, 0, 0, 1, 500000000, time.UTC),
+		time.Date(2026, 10, 16, 0, 0, 2, 0, time.UTC),
+	}
+
+	if len(samples) != len(expected) {
+		t.Fatalf("expected %d samples, got %v", len(expected), samples)
+	}
+
+	for i, sample := range samples {
+		if !sample.At.Equal(expected[i]) || sample.Client != "" {
+			t.Errorf("expected %v, got %+v", expected[i], sample)
+		}
+	}
+
+	if _, err := analyze.Read(strings.NewReader("yesterday\n"), analyze.Format{}); err == nil {
+		t.Error("expected an error for an unrecognized timestamp")
+	}
+}
+
+func TestReadCommonLog(t *testing.T) {
+	samples, err := analyze.Read(strings.NewReader(`10.0.0.1 - - [16/Oct/2026:00:00:00 +0000] "GET /config HTTP/1.1" 200 512
+garbage
+10.0.0.2 - frank [16/Oct/2026:02:00:05 +0200] "GET /config HTTP/1.1" 304 0 "-" "poller/1.0"
+`), analyze.CommonLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []analyze.Sample{
+		{Client: "10.0.0.1", At: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{Client: "10.0.0.2", At: time.Date(2026, 10, 16, 0, 0, 5, 0, time.UTC)},
+	}
+
+	if len(samples) != len(expected) {
+		t.Fatalf("expected %d samples, got %v", len(expected), samples)
+	}
+
+	for i, sample := range samples {
+		if sample.Client != expected[i].Client || !sample.At.Equal(expected[i].At) {
+			t.Errorf("expected %+v, got %+v", expected[i], sample)
+		}
+	}
+}
+
+func TestReadPattern(t *testing.T) {
+	format := analyze.Format{Pattern: regexp.MustCompile(`ts=(?P<time>\S+) .*user=(?P<client>\w+)`)}
+
+	samples, err := analyze.Read(strings.NewReader("ts=1791072000.5 level=info user=alice\n"), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 1 || samples[0].Client != "alice" || !samples[0].At.Equal(time.Unix(1791072000, 500000000)) {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+
+	format.Pattern = regexp.MustCompile(`user=(\w+)`)
+	if _, err := analyze.Read(strings.NewReader(""), format); err == nil {
+		t.Error("expected an error for a pattern without a time group")
+	}
+}
@@ -0,0 +1,44 @@
+package jitter
+
+import (
+	"math"
+	"time"
+)
+
+// Backoff computes exponentially growing delays with jitter for retrying failed operations
+// The zero value of every field except Base has a sensible default
+type Backoff struct {
+	Base   time.Duration // Delay before the first retry
+	Max    time.Duration // Max delay before jitter is applied, zero for no limit
+	Factor float64       // Growth of the delay per attempt, 2 when zero
+	Jitter float64       // Fraction of the delay that's randomized, from 0 for none to 1 for full jitter
+	Source *Source       // Source of randomness, nil for the runtime's generators
+}
+
+// Delay returns the delay before the given retry, counting from 0
+// The delay is Base*Factor^attempt capped at Max, of which the Jitter fraction is replaced by a random duration in the same range
+func (b Backoff) Delay(attempt int) time.Duration {
+	factor := b.Factor
+	if factor == 0 {
+		factor = 2
+	}
+
+	delay := float64(b.Base) * math.Pow(factor, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	// Converting floats beyond the range of durations would overflow
+	d := time.Duration(math.MaxInt64)
+	if delay < math.MaxInt64 {
+		d = time.Duration(delay)
+	}
+
+	fraction := math.Min(math.Max(b.Jitter, 0), 1)
+	j := time.Duration(float64(d) * fraction)
+	if j > d {
+		j = d
+	}
+
+	return b.Source.Duration(d-j, j)
+}
@@ -0,0 +1,66 @@
+package jitter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := jitter.Backoff{
+		Base: 100 * time.Millisecond,
+		Max:  time.Second,
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+
+	for attempt, delay := range expected {
+		if got := backoff.Delay(attempt); got != delay {
+			t.Errorf("Delay(%d) = %v, expected %v", attempt, got, delay)
+		}
+	}
+
+	if got := backoff.Delay(10000); got != time.Second {
+		t.Errorf("Delay(10000) = %v, expected %v", got, time.Second)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	backoff := jitter.Backoff{
+		Base:   time.Second,
+		Factor: 3,
+		Jitter: 0.5,
+		Source: jitter.NewSource(1),
+	}
+
+	for i := 0; i < 1000; i++ {
+		if got := backoff.Delay(1); got < 1500*time.Millisecond || got >= 3*time.Second {
+			t.Fatalf("Delay(1) = %v, out of range", got)
+		}
+	}
+
+	backoff.Jitter = 1
+	for i := 0; i < 1000; i++ {
+		if got := backoff.Delay(0); got < 0 || got >= time.Second {
+			t.Fatalf("Delay(0) = %v with full jitter, out of range", got)
+		}
+	}
+}
+
+func TestBackoffUncapped(t *testing.T) {
+	backoff := jitter.Backoff{
+		Base: time.Second,
+	}
+
+	if got := backoff.Delay(1000); got != time.Duration(1<<63-1) {
+		t.Errorf("Delay(1000) = %v, expected the max duration", got)
+	}
+}
@@ -0,0 +1,70 @@
+package jitter
+
+import (
+	"sort"
+	"time"
+)
+
+// Blackout is a set of periods in which events shouldn't happen, such as change freezes and holidays
+type Blackout interface {
+	// Blocked returns the end of the blackout period containing t, or false if t isn't blacked out
+	Blocked(t time.Time) (time.Time, bool)
+}
+
+// Window is the period of time from Start up to but not including End
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t is within the window
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Windows is a Blackout consisting of fixed windows, which may overlap
+type Windows []Window
+
+// Blocked returns the end of the blackout containing t, or false if none of the windows contains t
+// Overlapping and adjacent windows form a single blackout, so its end is past all of them
+func (w Windows) Blocked(t time.Time) (time.Time, bool) {
+	end := t
+	for extended := true; extended; {
+		extended = false
+		for _, window := range w {
+			if window.Contains(end) {
+				end = window.End
+				extended = true
+			}
+		}
+	}
+
+	return end, end.After(t)
+}
+
+// Merge returns the windows sorted by start, with overlapping and adjacent windows joined
+func (w Windows) Merge() Windows {
+	sorted := append(Windows(nil), w...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var merged Windows
+	for _, window := range sorted {
+		if !window.End.After(window.Start) {
+			continue
+		}
+
+		if last := len(merged) - 1; last >= 0 && !window.Start.After(merged[last].End) {
+			if window.End.After(merged[last].End) {
+				merged[last].End = window.End
+			}
+
+			continue
+		}
+
+		merged = append(merged, window)
+	}
+
+	return merged
+}
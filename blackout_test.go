@@ -0,0 +1,195 @@
+package jitter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+func TestWindowsBlocked(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	windows := jitter.Windows{
+		{Start: at(10), End: at(20)},
+		{Start: at(30), End: at(35)},
+		{Start: at(15), End: at(30)},
+	}
+
+	for _, test := range []struct {
+		at      int
+		blocked bool
+		end     int
+	}{
+		{at: 5},
+		{at: 10, blocked: true, end: 35},
+		{at: 17, blocked: true, end: 35},
+		{at: 30, blocked: true, end: 35},
+		{at: 35},
+	} {
+		end, blocked := windows.Blocked(at(test.at))
+		if blocked != test.blocked || (blocked && !end.Equal(at(test.end))) {
+			t.Errorf("Blocked(%dm) = %v, %v, expected %dm, %v", test.at, end.Sub(start), blocked, test.end, test.blocked)
+		}
+	}
+
+	merged := jitter.Windows{
+		{Start: at(40), End: at(50)},
+		{Start: at(0), End: at(10)},
+		{Start: at(10), End: at(20)},
+		{Start: at(5), End: at(8)},
+		{Start: at(60), End: at(60)},
+	}.Merge()
+
+	expected := jitter.Windows{
+		{Start: at(0), End: at(20)},
+		{Start: at(40), End: at(50)},
+	}
+
+	if len(merged) != len(expected) {
+		t.Fatalf("Merge() = %v, expected %v", merged, expected)
+	}
+
+	for i := range expected {
+		if !merged[i].Start.Equal(expected[i].Start) || !merged[i].End.Equal(expected[i].End) {
+			t.Errorf("Merge() = %v, expected %v", merged, expected)
+		}
+	}
+}
+
+func TestTickerBlackout(t *testing.T) {
+	start := time.Unix(0, 0)
+	freeze := jitter.Window{Start: start.Add(5 * time.Minute), End: start.Add(30 * time.Minute)}
+	blackout := jitter.Windows{freeze}
+
+	t.Run("skip", func(t *testing.T) {
+		clock := jittertest.NewClock(start)
+		ticker := jitter.NewTicker(time.Minute, time.Second, jitter.WithClock(clock), jitter.SkipDuring(blackout))
+		defer ticker.Stop()
+
+		upcoming := ticker.Upcoming(20)
+		for _, at := range upcoming {
+			if freeze.Contains(at) {
+				t.Errorf("event at %v during the blackout", at.Sub(start))
+			}
+		}
+
+		// 4 events before the freeze, the next one an interval after it
+		if after := upcoming[4].Sub(freeze.End); after < time.Minute || after >= time.Minute+time.Second {
+			t.Errorf("first event %v after the blackout, expected an interval", after)
+		}
+	})
+
+	t.Run("defer", func(t *testing.T) {
+		clock := jittertest.NewClock(start)
+		ticker := jitter.NewTicker(time.Minute, time.Second, jitter.WithClock(clock), jitter.DeferDuring(blackout))
+		defer ticker.Stop()
+
+		upcoming := ticker.Upcoming(20)
+		for _, at := range upcoming {
+			if freeze.Contains(at) {
+				t.Errorf("event at %v during the blackout", at.Sub(start))
+			}
+		}
+
+		if after := upcoming[4].Sub(freeze.End); after < 0 || after >= time.Second {
+			t.Errorf("deferred event %v after the blackout, expected within the jitter", after)
+		}
+	})
+
+	t.Run("defer rate preserving", func(t *testing.T) {
+		clock := jittertest.NewClock(start)
+		ticker := jitter.NewTicker(time.Minute, 10*time.Second, jitter.WithClock(clock), jitter.DeferDuring(blackout), jitter.RatePreserving(5*time.Second))
+		defer ticker.Stop()
+
+		upcoming := ticker.Upcoming(40)
+		deferred := 0
+		for i, at := range upcoming {
+			if freeze.Contains(at) {
+				t.Errorf("event at %v during the blackout", at.Sub(start))
+			}
+
+			if i > 0 && !at.After(upcoming[i-1]) {
+				t.Errorf("event at %v not after the previous one at %v", at.Sub(start), upcoming[i-1].Sub(start))
+			}
+
+			if !at.Before(freeze.End) && at.Before(freeze.End.Add(10*time.Second)) {
+				deferred++
+			}
+		}
+
+		// Only the first event in the blackout is deferred, the grid starts again from it
+		if deferred != 1 {
+			t.Errorf("%d events deferred to the end of the blackout, expected 1", deferred)
+		}
+	})
+}
+
+func TestTickerAdjacentBlackout(t *testing.T) {
+	start := time.Unix(0, 0)
+	count := 3 * jitter.MaxBlackoutPeriods / 2
+
+	// Adjacent windows, listed last first, which form a single blackout longer than MaxBlackoutPeriods windows
+	var blackout jitter.Windows
+	for i := count; i > 0; i-- {
+		blackout = append(blackout, jitter.Window{Start: start.Add(time.Duration(i) * time.Minute), End: start.Add(time.Duration(i+1) * time.Minute)})
+	}
+
+	end := start.Add(time.Duration(count+1) * time.Minute)
+	for name, option := range map[string]jitter.Option{"skip": jitter.SkipDuring(blackout), "defer": jitter.DeferDuring(blackout)} {
+		t.Run(name, func(t *testing.T) {
+			clock := jittertest.NewClock(start)
+			ticker := jitter.NewTicker(30*time.Second, time.Second, jitter.WithClock(clock), option)
+			defer ticker.Stop()
+
+			upcoming := ticker.Upcoming(3)
+			if len(upcoming) != 3 {
+				t.Fatalf("expected the ticker to keep running, got %v", upcoming)
+			}
+
+			if upcoming[1].Before(end) {
+				t.Errorf("expected the second event after the blackout ends at %v, got %v", end, upcoming[1])
+			}
+		})
+	}
+}
+
+// endless is a blackout made of back-to-back hourly periods that never ends
+type endless struct{}
+
+func (endless) Blocked(t time.Time) (time.Time, bool) {
+	return t.Truncate(time.Hour).Add(time.Hour), true
+}
+
+func TestTickerEndlessBlackout(t *testing.T) {
+	for name, option := range map[string]jitter.Option{"skip": jitter.SkipDuring(endless{}), "defer": jitter.DeferDuring(endless{})} {
+		t.Run(name, func(t *testing.T) {
+			clock := jittertest.NewClock(time.Unix(0, 0))
+			ticker := jitter.NewTicker(time.Minute, time.Second, jitter.WithClock(clock), option)
+			defer ticker.Stop()
+
+			if next := ticker.Next(); !next.IsZero() {
+				t.Errorf("expected the ticker to stop, next event at %v", next)
+			}
+
+			if upcoming := ticker.Upcoming(3); upcoming != nil {
+				t.Errorf("expected no upcoming events, got %v", upcoming)
+			}
+
+			// A deadline still applies, however far away it is
+			for _, after := range []time.Duration{10 * time.Minute, 365 * 24 * time.Hour} {
+				deadline := time.Unix(0, 0).Add(after)
+				ticker := jitter.NewTicker(time.Minute, time.Second, jitter.WithClock(clock), option, jitter.HardDeadline(deadline, 0))
+				defer ticker.Stop()
+
+				if upcoming := ticker.Upcoming(3); len(upcoming) != 1 || !upcoming[0].Equal(deadline) {
+					t.Errorf("expected a single event at the deadline %v, got %v", deadline, upcoming)
+				}
+			}
+		})
+	}
+}
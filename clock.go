@@ -0,0 +1,30 @@
+package jitter
+
+import (
+	"time"
+)
+
+// Clock tells the time and runs functions after a delay
+// Tickers and helpers take a Clock so that they can be driven by a fake one in tests and simulations
+type Clock interface {
+	Now() time.Time                            // Returns the current time
+	AfterFunc(d time.Duration, f func()) Timer // Calls f in its own goroutine once d has elapsed
+}
+
+// Timer is a pending call created by Clock.AfterFunc
+type Timer interface {
+	Stop() bool // Prevents the call if it hasn't started yet and reports whether it did so
+}
+
+// RealClock is the Clock backed by the time package
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
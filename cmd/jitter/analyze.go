@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gerifield/jitter/analyze"
+)
+
+// runAnalyze analyzes request timestamps read from files or stdin, and reports the clients needing more jitter
+func runAnalyze(args []string) int {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	clf := flags.Bool("clf", false, "read access logs in the Common or Combined Log Format, with the remote host as the client")
+	pattern := flags.String("regex", "", "regular expression extracting the (?P<time>...) and optionally the (?P<client>...) of each line")
+	layout := flags.String("layout", "", "Go layout of the timestamps, detected when empty")
+	period := flags.Duration("period", 0, "period to analyze the phases at, detected when zero")
+	coverage := flags.Float64("coverage", 0.9, "fraction of the requests the reported spread covers")
+	minJitter := flags.Float64("min-jitter", 0.1, "jitter as a fraction of the period below which periodic clients are flagged")
+	top := flags.Int("top", 20, "number of clients listed, zero for all")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: jitter analyze [flags] [file ...]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	format := analyze.Format{Layout: *layout}
+	if *clf {
+		format = analyze.CommonLog
+	}
+
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		if err != nil {
+			log.Printf("invalid regex: %v", err)
+			return 2
+		}
+
+		format.Pattern = re
+	}
+
+	samples, err := readSamples(flags.Args(), format)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	overall, clients := analyze.AnalyzeClients(samples, analyze.Config{
+		Period:   *period,
+		Coverage: *coverage,
+	})
+
+	printOverall(os.Stdout, overall, len(clients), *coverage)
+	if len(clients) > 1 || (len(clients) == 1 && clients[0].Client != "") {
+		printClients(os.Stdout, clients, *minJitter, *top)
+	}
+
+	return 0
+}
+
+// readSamples reads the samples of the files, or of stdin when there are none
+func readSamples(paths []string, format analyze.Format) ([]analyze.Sample, error) {
+	if len(paths) == 0 {
+		return analyze.Read(os.Stdin, format)
+	}
+
+	var samples []analyze.Sample
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open samples: %w", err)
+		}
+
+		read, err := analyze.Read(f, format)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		samples = append(samples, read...)
+	}
+
+	return samples, nil
+}
+
+func printOverall(w io.Writer, overall analyze.Report, clients int, coverage float64) {
+	fmt.Fprintf(w, "%d requests from %d clients over %s\n", overall.Count, clients, overall.Last.Sub(overall.First))
+	if overall.Period == 0 {
+		fmt.Fprintln(w, "no period detected")
+		return
+	}
+
+	verdict := "not clustered"
+	if overall.Clustered {
+		verdict = "CLUSTERED"
+	}
+
+	fmt.Fprintf(w, "period %s: %s (clustering %.2f), %.0f%% of requests within a %s window at offset %s\n",
+		overall.Period, verdict, overall.Clustering, coverage*100, round(overall.Spread), round(overall.Phase))
+}
+
+// printClients lists the clients, the flagged ones first from the least jitter
+func printClients(w io.Writer, clients []analyze.Report, minJitter float64, top int) {
+	flagged := func(r analyze.Report) bool {
+		return r.Period > 0 && r.JitterRatio() < minJitter
+	}
+
+	sort.SliceStable(clients, func(i, j int) bool {
+		if flagged(clients[i]) != flagged(clients[j]) {
+			return flagged(clients[i])
+		}
+
+		return flagged(clients[i]) && clients[i].JitterRatio() < clients[j].JitterRatio()
+	})
+
+	if top > 0 && len(clients) > top {
+		clients = clients[:top]
+	}
+
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLIENT\tREQUESTS\tPERIOD\tJITTER\tRATIO\t")
+	for _, r := range clients {
+		if r.Period == 0 {
+			fmt.Fprintf(tw, "%s\t%d\t-\t-\t-\t\n", r.Client, r.Count)
+			continue
+		}
+
+		note := ""
+		if flagged(r) {
+			note = "needs more jitter"
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.2f\t%s\n", r.Client, r.Count, round(r.Period), round(r.Jitter), r.JitterRatio(), note)
+	}
+
+	tw.Flush()
+}
+
+// round rounds a duration for display
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second)
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
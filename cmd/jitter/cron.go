@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gerifield/jitter/cron"
+)
+
+// runCron runs the jobs of a crontab until interrupted, reloading it on SIGHUP
+func runCron(args []string) int {
+	flags := flag.NewFlagSet("cron", flag.ContinueOnError)
+	shell := flags.String("shell", "/bin/sh", "shell the commands are run with")
+	killDelay := flags.Duration("kill-delay", 10*time.Second, "time between terminating a timed out command and killing it")
+	tz := flags.String("tz", "Local", "time zone the schedules are matched in")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: jitter cron [flags] crontab")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Printf("invalid time zone: %v", err)
+		return 2
+	}
+
+	entries, err := readCrontab(path)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	c := cron.New(cron.Config{
+		Shell:     *shell,
+		KillDelay: *killDelay,
+		Location:  loc,
+	})
+	c.Load(entries)
+	log.Printf("loaded %d jobs from %s", len(entries), path)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			log.Printf("received %s, waiting for running jobs to finish", sig)
+			break
+		}
+
+		// Keep the current jobs when the crontab is broken
+		entries, err := readCrontab(path)
+		if err != nil {
+			log.Printf("not reloading: %v", err)
+			continue
+		}
+
+		c.Load(entries)
+		log.Printf("reloaded %d jobs from %s", len(entries), path)
+	}
+
+	signal.Stop(signals)
+	c.Stop()
+
+	return 0
+}
+
+// readCrontab reads the entries of a crontab file
+func readCrontab(path string) ([]cron.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crontab: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := cron.ParseTab(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid crontab %s: %w", path, err)
+	}
+
+	return entries, nil
+}
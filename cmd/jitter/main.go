@@ -0,0 +1,50 @@
+// Command jitter provides tools built on the jitter package
+//
+// Usage:
+//
+//	jitter cron [flags] crontab
+//	jitter analyze [flags] [file ...]
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// commands are the subcommands by name, each one is called with the arguments following its name and returns the exit code
+var commands = map[string]func(args []string) int{
+	"cron":    runCron,
+	"analyze": runAnalyze,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "jitter: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	os.Exit(command(os.Args[2:]))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: jitter <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", name)
+	}
+}
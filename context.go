@@ -0,0 +1,28 @@
+package jitter
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout returns a copy of the context that's cancelled after d plus a random jitter in [0, j), like context.WithTimeout
+// Jittering the timeouts of clients keeps them from timing out, and retrying, in sync
+func WithTimeout(ctx context.Context, d, j time.Duration) (context.Context, context.CancelFunc) {
+	return (*Source)(nil).WithTimeout(ctx, d, j)
+}
+
+// WithDeadline returns a copy of the context that's cancelled at t plus a random jitter in [0, j), like context.WithDeadline
+// The deadline of the parent still applies when it's earlier
+func WithDeadline(ctx context.Context, t time.Time, j time.Duration) (context.Context, context.CancelFunc) {
+	return (*Source)(nil).WithDeadline(ctx, t, j)
+}
+
+// WithTimeout returns a copy of the context that's cancelled after d plus a random jitter in [0, j), like context.WithTimeout
+func (s *Source) WithTimeout(ctx context.Context, d, j time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.Duration(d, j))
+}
+
+// WithDeadline returns a copy of the context that's cancelled at t plus a random jitter in [0, j), like context.WithDeadline
+func (s *Source) WithDeadline(ctx context.Context, t time.Time, j time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, t.Add(s.Duration(0, j)))
+}
@@ -0,0 +1,92 @@
+package jitter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+func TestWithTimeout(t *testing.T) {
+	d := time.Hour
+	j := time.Minute
+
+	var lowest, highest time.Duration
+	for i := 0; i < 100; i++ {
+		before := time.Now()
+		ctx, cancel := jitter.WithTimeout(context.Background(), d, j)
+		after := time.Now()
+		cancel()
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Fatal("expected a deadline")
+		}
+
+		if deadline.Before(before.Add(d)) || !deadline.Before(after.Add(d+j)) {
+			t.Fatalf("deadline %v out of range [%v, %v)", deadline, before.Add(d), after.Add(d+j))
+		}
+
+		if offset := deadline.Sub(before); i == 0 {
+			lowest, highest = offset, offset
+		} else {
+			lowest, highest = min(lowest, offset), max(highest, offset)
+		}
+	}
+
+	if highest-lowest < time.Second {
+		t.Error("expected the deadlines to vary")
+	}
+
+	ctx, cancel := jitter.WithTimeout(context.Background(), time.Millisecond, time.Millisecond)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		if ctx.Err() != context.DeadlineExceeded {
+			t.Errorf("expected the deadline to be exceeded, got %v", ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Error("context not done after the timeout")
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+	j := time.Minute
+
+	for i := 0; i < 100; i++ {
+		ctx, cancel := jitter.WithDeadline(context.Background(), at, j)
+		cancel()
+
+		if deadline, _ := ctx.Deadline(); deadline.Before(at) || !deadline.Before(at.Add(j)) {
+			t.Fatalf("deadline %v out of range [%v, %v)", deadline, at, at.Add(j))
+		}
+	}
+
+	// The earlier deadline of the parent applies
+	parent, cancel := context.WithDeadline(context.Background(), at)
+	defer cancel()
+
+	ctx, cancel := jitter.WithDeadline(parent, at.Add(time.Hour), j)
+	defer cancel()
+
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(at) {
+		t.Errorf("expected the deadline of the parent %v, got %v", at, deadline)
+	}
+}
+
+func TestSourceWithDeadline(t *testing.T) {
+	a, cancelA := jitter.NewSource(1).WithDeadline(context.Background(), time.Unix(0, 0), time.Minute)
+	defer cancelA()
+
+	b, cancelB := jitter.NewSource(1).WithDeadline(context.Background(), time.Unix(0, 0), time.Minute)
+	defer cancelB()
+
+	deadlineA, _ := a.Deadline()
+	deadlineB, _ := b.Deadline()
+	if !deadlineA.Equal(deadlineB) {
+		t.Errorf("expected the same deadline from the same seed, got %v and %v", deadlineA, deadlineB)
+	}
+}
@@ -0,0 +1,310 @@
+package cron
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Config configures a Cron
+type Config struct {
+	Shell     string         // Shell the commands are run with as Shell -c command, /bin/sh when empty
+	KillDelay time.Duration  // Time between terminating a timed out command and killing it, 10 seconds when zero
+	Location  *time.Location // Location the schedules are matched in, time.Local when nil
+	Logger    *log.Logger    // Logger for the runs and the output of the jobs, log.Default() when nil
+
+	Run func(ctx context.Context, entry Entry) error // Runs a job, runs its command in the shell when nil
+
+	Clock  jitter.Clock   // Clock the jobs are scheduled on, jitter.RealClock when nil
+	Source *jitter.Source // Source of randomness for generating jitter
+}
+
+// Cron runs jobs on their schedules with jitter
+// A job never overlaps itself, runs whose time with jitter passes while the previous one is still going are skipped
+type Cron struct {
+	cfg Config
+
+	mu   sync.Mutex
+	jobs map[string]*job // Jobs by the keys of their entries
+	wg   sync.WaitGroup  // Scheduling loops and running commands
+}
+
+// job is a scheduled entry
+type job struct {
+	entry  Entry
+	line   atomic.Int64       // Current line of the entry, which changes when lines above it are edited
+	cancel context.CancelFunc // Stops scheduling the job, a running command is left to finish
+}
+
+// New returns a new cron without any jobs
+func New(cfg Config) *Cron {
+	if cfg.Shell == "" {
+		cfg.Shell = "/bin/sh"
+	}
+
+	if cfg.KillDelay <= 0 {
+		cfg.KillDelay = 10 * time.Second
+	}
+
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = jitter.RealClock
+	}
+
+	return &Cron{
+		cfg:  cfg,
+		jobs: make(map[string]*job),
+	}
+}
+
+// Load replaces the jobs with the given entries
+// Jobs whose entries are unchanged keep their schedule, so reloading doesn't make them run twice or overlap
+// Removed jobs stop being scheduled, but a running command is left to finish
+func (c *Cron) Load(entries []Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make(map[string]int)
+	jobs := make(map[string]*job, len(entries))
+	for _, entry := range entries {
+		// Identical entries are separate jobs
+		key := entry.Key()
+		keys[key]++
+		key = fmt.Sprintf("%s\x00%d", key, keys[key])
+
+		if j, ok := c.jobs[key]; ok {
+			j.line.Store(int64(entry.Line))
+			jobs[key] = j
+			delete(c.jobs, key)
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		j := &job{
+			entry:  entry,
+			cancel: cancel,
+		}
+
+		j.line.Store(int64(entry.Line))
+		jobs[key] = j
+		c.wg.Add(1)
+		go c.loop(ctx, j)
+	}
+
+	for _, j := range c.jobs {
+		j.cancel()
+	}
+
+	c.jobs = jobs
+}
+
+// Stop stops scheduling the jobs and waits for the running commands to finish
+func (c *Cron) Stop() {
+	c.Load(nil)
+	c.wg.Wait()
+}
+
+// loop runs the job on its schedule until the context is cancelled
+func (c *Cron) loop(ctx context.Context, j *job) {
+	defer c.wg.Done()
+
+	now := c.cfg.Clock.Now().In(c.cfg.Location)
+	next, at := c.following(j, now, now)
+	for !next.IsZero() {
+		if !sleep(ctx, c.cfg.Clock, at.Sub(now)) {
+			return
+		}
+
+		c.run(ctx, j)
+
+		// Go on from the run that was due rather than the time it finished, so the jitter and the run time don't add up
+		now = c.cfg.Clock.Now().In(c.cfg.Location)
+		next, at = c.following(j, next, now)
+	}
+
+	c.logf(j, "no upcoming runs for schedule %q", j.entry.Spec)
+}
+
+// following returns the first run due after prev whose time with jitter isn't before now, along with that time
+// The runs passed over were due while the previous run was still going, they are skipped
+func (c *Cron) following(j *job, prev time.Time, now time.Time) (next time.Time, at time.Time) {
+	var skipped time.Time
+	for next = j.entry.Schedule.Next(prev); !next.IsZero(); next = j.entry.Schedule.Next(next) {
+		if at = c.jitter(j.entry, next); !at.Before(now) {
+			break
+		}
+
+		if skipped.IsZero() {
+			skipped = next
+		}
+	}
+
+	if !skipped.IsZero() {
+		c.logf(j, "skipped the runs due since %s, as the previous run was still going", skipped.Format(time.RFC3339))
+	}
+
+	return next, at
+}
+
+// jitter returns the scheduled time of a run with jitter added
+// The jitter is the larger of the jitter of the entry and its splay of the time to the following run
+func (c *Cron) jitter(entry Entry, next time.Time) time.Time {
+	max := entry.Jitter
+	if entry.Splay > 0 {
+		if following := entry.Schedule.Next(next); !following.IsZero() {
+			max = maxDuration(max, time.Duration(float64(following.Sub(next))*entry.Splay))
+		}
+	}
+
+	return next.Add(c.cfg.Source.Duration(0, max))
+}
+
+// run runs the job once, a run isn't interrupted when the job stops being scheduled
+func (c *Cron) run(ctx context.Context, j *job) {
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	ctx = context.WithoutCancel(ctx)
+	if j.entry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.entry.Timeout)
+		defer cancel()
+	}
+
+	c.logf(j, "starting %s", j.entry.Command)
+	start := c.cfg.Clock.Now()
+
+	var err error
+	if c.cfg.Run != nil {
+		err = c.cfg.Run(ctx, j.entry)
+	} else {
+		err = c.exec(ctx, j)
+	}
+	elapsed := c.cfg.Clock.Now().Sub(start)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		c.logf(j, "timed out after %s", elapsed)
+	case err != nil:
+		c.logf(j, "failed after %s: %v", elapsed, err)
+	default:
+		c.logf(j, "finished after %s", elapsed)
+	}
+}
+
+// exec runs the command of a job in the shell, logging its output
+// When the context is done the command and the processes it started are terminated, and killed if any of them are
+// still running after the kill delay, so a run that timed out can't overlap the next one
+func (c *Cron) exec(ctx context.Context, j *job) error {
+	cmd := exec.CommandContext(ctx, c.cfg.Shell, "-c", j.entry.Command)
+	cmd.Env = append(os.Environ(), j.entry.Env...)
+	setProcessGroup(cmd)
+
+	terminated := make(chan time.Time, 1)
+	cmd.Cancel = func() error {
+		terminated <- time.Now()
+		return terminateGroup(cmd.Process)
+	}
+	cmd.WaitDelay = c.cfg.KillDelay
+
+	output := c.output(j)
+	defer output.Close()
+
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+
+	select {
+	case at := <-terminated:
+		// The shell may be gone while the processes it started are still running
+		deadline := at.Add(c.cfg.KillDelay)
+		for groupAlive(cmd.Process) && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		killGroup(cmd.Process)
+	default:
+	}
+
+	return err
+}
+
+// output returns a writer logging every line written to it as output of the job
+func (c *Cron) output(j *job) io.WriteCloser {
+	r, w := io.Pipe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			c.logf(j, "> %s", scanner.Text())
+		}
+
+		r.CloseWithError(scanner.Err()) // Unblock the writer if a line is too long
+	}()
+
+	return &lineLogger{PipeWriter: w, done: done}
+}
+
+// lineLogger is the writing end of a pipe whose lines are logged
+type lineLogger struct {
+	*io.PipeWriter
+	done chan struct{}
+}
+
+// Close closes the pipe and waits for the remaining lines to be logged
+func (l *lineLogger) Close() error {
+	err := l.PipeWriter.Close()
+	<-l.done
+
+	return err
+}
+
+// logf logs a message about a job
+func (c *Cron) logf(j *job, format string, args ...interface{}) {
+	c.cfg.Logger.Printf("job on line %d: %s", j.line.Load(), fmt.Sprintf(format, args...))
+}
+
+// sleep waits for the duration on the clock and reports whether it passed before the context was done
+func sleep(ctx context.Context, clock jitter.Clock, d time.Duration) bool {
+	done := make(chan struct{})
+	timer := clock.AfterFunc(d, func() {
+		close(done)
+	})
+
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return false
+	case <-done:
+		return true
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,278 @@
+package cron_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/cron"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+// runs records the times jobs are run at
+type runs struct {
+	mu    sync.Mutex
+	times map[string][]time.Time
+	clock jitter.Clock
+}
+
+func (r *runs) run(ctx context.Context, entry cron.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.times[entry.Command] = append(r.times[entry.Command], r.clock.Now())
+	return nil
+}
+
+func (r *runs) get(command string) []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]time.Time(nil), r.times[command]...)
+}
+
+// waitPending waits until n timers are pending on the clock
+func waitPending(t *testing.T, clock *jittertest.Clock, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for clock.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d pending timers, got %d", n, clock.Pending())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func parse(t *testing.T, tab string) []cron.Entry {
+	t.Helper()
+
+	entries, err := cron.ParseTab(strings.NewReader(tab))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return entries
+}
+
+func TestCronJitter(t *testing.T) {
+	clock := jittertest.NewClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	r := &runs{times: make(map[string][]time.Time), clock: clock}
+
+	c := cron.New(cron.Config{
+		Location: time.UTC,
+		Logger:   log.New(&bytes.Buffer{}, "", 0),
+		Run:      r.run,
+		Clock:    clock,
+		Source:   jitter.NewSource(1),
+	})
+	defer c.Stop()
+
+	c.Load(parse(t, "@hourly jitter=10m a\n0 * * * * splay=50% b\n"))
+
+	// Advance in small steps, waiting for the jobs to be scheduled again after every run
+	for i := 0; i < 3*3600; i++ {
+		waitPending(t, clock, 2)
+		clock.Advance(time.Second)
+	}
+
+	for command, max := range map[string]time.Duration{"a": 10 * time.Minute, "b": 30 * time.Minute} {
+		times := r.get(command)
+		if len(times) < 2 {
+			t.Fatalf("%s: expected at least 2 runs, got %v", command, times)
+		}
+
+		for _, at := range times {
+			if offset := at.Sub(at.Truncate(time.Hour)); offset > max {
+				t.Errorf("%s: run at %v is more than %v past the hour", command, at, max)
+			}
+		}
+	}
+}
+
+func TestCronJitterKeepsSchedule(t *testing.T) {
+	clock := jittertest.NewClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	r := &runs{times: make(map[string][]time.Time), clock: clock}
+
+	c := cron.New(cron.Config{
+		Location: time.UTC,
+		Logger:   log.New(&bytes.Buffer{}, "", 0),
+		Run: func(ctx context.Context, entry cron.Entry) error {
+			r.run(ctx, entry)
+
+			// Every run takes a minute
+			done := make(chan struct{})
+			clock.AfterFunc(time.Minute, func() {
+				close(done)
+			})
+
+			<-done
+			return nil
+		},
+		Clock:  clock,
+		Source: jitter.NewSource(1),
+	})
+
+	c.Load(parse(t, "@every 1h jitter=10m a\n*/5 * * * * splay=100% b\n"))
+
+	// Both jobs always have a timer pending, either for the next run or for the running one to finish
+	for i := 0; i < 10*3600; i++ {
+		waitPending(t, clock, 2)
+		clock.Advance(time.Second)
+	}
+
+	c.Load(nil)
+	clock.Advance(time.Minute)
+	c.Stop()
+
+	// The runs don't drift away from the schedule
+	a := r.get("a")
+	if len(a) < 9 {
+		t.Errorf("a: expected at least 9 runs, got %v", a)
+	}
+
+	for _, at := range a {
+		if offset := at.Sub(at.Truncate(time.Hour)); offset > 10*time.Minute {
+			t.Errorf("a: run at %v is more than 10m past the hour", at)
+		}
+	}
+
+	// A run that's late doesn't drop the next one, unless the jitter makes it due before the late run finishes
+	b := r.get("b")
+	if len(b) < 110 {
+		t.Errorf("b: expected at least 110 of the 120 runs, got %d", len(b))
+	}
+
+	for i := 1; i < len(b); i++ {
+		if b[i].Sub(b[i-1]) < time.Minute {
+			t.Errorf("b: run at %v overlaps the one at %v", b[i], b[i-1])
+		}
+	}
+}
+
+func TestCronReload(t *testing.T) {
+	clock := jittertest.NewClock(time.Date(2026, 10, 16, 0, 0, 30, 0, time.UTC))
+	r := &runs{times: make(map[string][]time.Time), clock: clock}
+
+	c := cron.New(cron.Config{
+		Location: time.UTC,
+		Logger:   log.New(&bytes.Buffer{}, "", 0),
+		Run:      r.run,
+		Clock:    clock,
+	})
+	defer c.Stop()
+
+	c.Load(parse(t, "* * * * * a\n* * * * * b\n"))
+	waitPending(t, clock, 2)
+
+	// The unchanged job keeps its timer, the removed one is stopped and the new one scheduled
+	c.Load(parse(t, "# Comment moving the lines\n* * * * * a\n* * * * * c\n"))
+	time.Sleep(10 * time.Millisecond) // Let the removed job stop its timer
+	waitPending(t, clock, 2)
+
+	clock.Advance(time.Minute)
+	waitPending(t, clock, 2)
+
+	for command, expected := range map[string]int{"a": 1, "b": 0, "c": 1} {
+		if runs := len(r.get(command)); runs != expected {
+			t.Errorf("%s: expected %d runs, got %d", command, expected, runs)
+		}
+	}
+}
+
+func TestCronNoOverlap(t *testing.T) {
+	clock := jittertest.NewClock(time.Date(2026, 10, 16, 0, 0, 30, 0, time.UTC))
+
+	var logs bytes.Buffer
+	var mu sync.Mutex
+	var running, overlaps, count int
+
+	c := cron.New(cron.Config{
+		Location: time.UTC,
+		Logger:   log.New(&syncWriter{w: &logs, mu: &mu}, "", 0),
+		Run: func(ctx context.Context, entry cron.Entry) error {
+			mu.Lock()
+			running++
+			count++
+			if running > 1 {
+				overlaps++
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		},
+		Clock: clock,
+	})
+	defer c.Stop()
+
+	c.Load(parse(t, "* * * * * a\n"))
+	waitPending(t, clock, 1)
+	clock.Advance(5 * time.Minute)
+
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if overlaps > 0 || count != 1 {
+		t.Errorf("expected a single run without overlaps, got %d runs and %d overlaps", count, overlaps)
+	}
+
+	if !strings.Contains(logs.String(), "skipped the runs due since") {
+		t.Errorf("expected the skipped runs to be logged:\n%s", logs.String())
+	}
+}
+
+// syncWriter serialises writes to a buffer that's read by the test
+type syncWriter struct {
+	w  *bytes.Buffer
+	mu *sync.Mutex
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+func TestCronExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell available")
+	}
+
+	var mu sync.Mutex
+	var logs bytes.Buffer
+
+	c := cron.New(cron.Config{
+		Shell:     "sh",
+		KillDelay: 100 * time.Millisecond,
+		Logger:    log.New(&syncWriter{w: &logs, mu: &mu}, "", 0),
+	})
+
+	c.Load(parse(t, "GREETING=hello\n@every 10ms echo $GREETING\n@every 10ms timeout=20ms sleep 10\n"))
+	time.Sleep(200 * time.Millisecond)
+	c.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, expected := range []string{"job on line 2: > hello", "job on line 2: finished", "job on line 3: timed out"} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("expected %q in the logs:\n%s", expected, logs.String())
+		}
+	}
+}
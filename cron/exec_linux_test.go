@@ -0,0 +1,81 @@
+package cron_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/cron"
+)
+
+func TestCronTimeoutKillsChildren(t *testing.T) {
+	var mu sync.Mutex
+	var logs bytes.Buffer
+
+	c := cron.New(cron.Config{
+		Shell:     "sh",
+		KillDelay: 100 * time.Millisecond,
+		Logger:    log.New(&syncWriter{w: &logs, mu: &mu}, "", 0),
+	})
+
+	// A compound line keeps the shell from replacing itself with sleep, which then runs as its child
+	c.Load(parse(t, "@every 10ms timeout=50ms sleep 37.25; echo done\n"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(running(t, "sleep\x0037.25\x00")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("command didn't start")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	c.Stop()
+
+	if pids := running(t, "sleep\x0037.25\x00"); len(pids) > 0 {
+		t.Errorf("expected the processes of the timed out runs to be gone, found %v", pids)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !strings.Contains(logs.String(), "timed out") || strings.Contains(logs.String(), "> done") {
+		t.Errorf("expected the runs to time out:\n%s", logs.String())
+	}
+}
+
+// running returns the processes running the command line, leaving out the ones that have exited but haven't been reaped
+func running(t *testing.T, cmdline string) []string {
+	t.Helper()
+
+	dirs, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pids []string
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil || string(data) != cmdline {
+			continue // Gone, or running something else
+		}
+
+		// The state follows the parenthesised command
+		stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+
+		if fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:])); len(fields) > 0 && fields[0] != "Z" {
+			pids = append(pids, filepath.Base(dir))
+		}
+	}
+
+	return pids
+}
@@ -0,0 +1,26 @@
+//go:build !unix
+
+package cron
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing, as process groups are only supported on Unix
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateGroup kills the process, as only the process itself can be signalled
+func terminateGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+// killGroup kills the process
+func killGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+// groupAlive reports false, as the children of the process can't be tracked
+func groupAlive(p *os.Process) bool {
+	return false
+}
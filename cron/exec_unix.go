@@ -0,0 +1,42 @@
+//go:build unix
+
+package cron
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes the command lead a process group of its own, so it can be signalled along with its children
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateGroup asks the process group led by p to terminate
+func terminateGroup(p *os.Process) error {
+	return signalGroup(p, syscall.SIGTERM)
+}
+
+// killGroup kills the process group led by p
+func killGroup(p *os.Process) error {
+	return signalGroup(p, syscall.SIGKILL)
+}
+
+// groupAlive reports whether any process of the group led by p is left
+func groupAlive(p *os.Process) bool {
+	return syscall.Kill(-p.Pid, 0) == nil
+}
+
+func signalGroup(p *os.Process, sig syscall.Signal) error {
+	if err := syscall.Kill(-p.Pid, sig); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+// Package cron runs commands on cron schedules with an added jitter, so the jobs of a fleet don't all start at the top of the minute
+// Jobs are read from a crontab-like file, see ParseTab for its format
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron schedule
+type Schedule struct {
+	minute uint64 // Bit set of the minutes matching the schedule
+	hour   uint64 // Bit set of the hours
+	dom    uint64 // Bit set of the days of the month
+	month  uint64 // Bit set of the months
+	dow    uint64 // Bit set of the days of the week, Sunday is 0
+
+	anyDOM bool // Whether the day of month field started with a wildcard
+	anyDOW bool // Whether the day of week field started with a wildcard
+
+	every time.Duration // Fixed interval of @every schedules, zero for field schedules
+}
+
+// field describes the allowed values of a schedule field
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minutes = field{name: "minute", min: 0, max: 59}
+	hours   = field{name: "hour", min: 0, max: 23}
+	doms    = field{name: "day of month", min: 1, max: 31}
+	months  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dows = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule of five fields, minute, hour, day of month, month and day of week, or one of the macros
+// @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly and @every <duration>
+// Fields accept *, values, ranges, steps and lists such as 1-10/2,30, months and days of the week also accept names
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid interval: %q", every)
+		}
+
+		return &Schedule{every: d}, nil
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := macros[spec]
+		if !ok {
+			return nil, fmt.Errorf("unknown macro: %q", spec)
+		}
+
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d: %q", len(fields), spec)
+	}
+
+	s := &Schedule{
+		anyDOM: strings.HasPrefix(fields[2], "*"),
+		anyDOW: strings.HasPrefix(fields[4], "*"),
+	}
+
+	for i, f := range []struct {
+		bits *uint64
+		field
+	}{
+		{&s.minute, minutes},
+		{&s.hour, hours},
+		{&s.dom, doms},
+		{&s.month, months},
+		{&s.dow, dows},
+	} {
+		bits, err := f.parse(fields[i])
+		if err != nil {
+			return nil, err
+		}
+
+		*f.bits = bits
+	}
+
+	// Both 0 and 7 are Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+// parse parses a field into a bit set of the values it matches
+func (f field) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		expr, stepSpec, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step: %q", f.name, part)
+			}
+		}
+
+		low, high := f.min, f.max
+		if expr != "*" {
+			lowSpec, highSpec, isRange := strings.Cut(expr, "-")
+
+			var err error
+			if low, err = f.value(lowSpec); err != nil {
+				return 0, err
+			}
+
+			high = low
+			if isRange {
+				if high, err = f.value(highSpec); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = f.max // A value with a step runs up to the max, like 5/15
+			}
+
+			if high < low {
+				return 0, fmt.Errorf("invalid %s range: %q", f.name, part)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a single value of the field
+func (f field) value(spec string) (int, error) {
+	if v, ok := f.names[strings.ToLower(spec)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(spec)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s: %q", f.name, spec)
+	}
+
+	return v, nil
+}
+
+// Next returns the first time after t matching the schedule, or the zero time if there's none within five years
+// Times are matched in the location of t
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchDay reports whether the day of t matches the schedule
+// When both the day of month and the day of week are restricted a day matching either of them matches, like in cron
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.anyDOM || s.anyDOW {
+		return dom && dow
+	}
+
+	return dom || dow
+}
@@ -0,0 +1,68 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/cron"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC) // A Friday
+
+	for spec, expected := range map[string]time.Time{
+		"* * * * *":          time.Date(2026, 10, 16, 10, 8, 0, 0, time.UTC),
+		"*/15 * * * *":       time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC),
+		"5/20 * * * *":       time.Date(2026, 10, 16, 10, 25, 0, 0, time.UTC),
+		"0 9-17 * * mon-fri": time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC),
+		"30 2 * * 7":         time.Date(2026, 10, 18, 2, 30, 0, 0, time.UTC),
+		"0 0 1,15 * *":       time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 13 * fri":       time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 6), // The next Friday, matching either day field
+		"0 0 29 feb *":       time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		"@hourly":            time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC),
+		"@yearly":            time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		"@every 90s":         from.Add(90 * time.Second),
+	} {
+		schedule, err := cron.Parse(spec)
+		if err != nil {
+			t.Errorf("%q: %v", spec, err)
+			continue
+		}
+
+		if next := schedule.Next(from); !next.Equal(expected) {
+			t.Errorf("%q: expected %v, got %v", spec, expected, next)
+		}
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	schedule, err := cron.Parse("0 0 30 feb *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected no next time, got %v", next)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@fortnightly",
+		"@every soon",
+		"@every -1m",
+	} {
+		if _, err := cron.Parse(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
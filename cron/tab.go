@@ -0,0 +1,155 @@
+package cron
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a job of a crontab
+type Entry struct {
+	Line     int           // Line of the entry in the crontab
+	Spec     string        // Schedule as written in the crontab
+	Schedule *Schedule     // Schedule of the job
+	Jitter   time.Duration // Max jitter added to the scheduled times
+	Splay    float64       // Max jitter added to the scheduled times as a fraction of the time to the following run
+	Timeout  time.Duration // Time after which a run is terminated, zero for no limit
+	Command  string        // Command run by the shell
+	Env      []string      // Environment variables set above the entry, in the form NAME=value
+}
+
+// Key identifies the job of an entry, entries with the same key run the same command on the same schedule
+func (e Entry) Key() string {
+	return fmt.Sprintf("%s\x00%v\x00%v\x00%v\x00%s\x00%s", e.Spec, e.Jitter, e.Splay, e.Timeout, e.Command, strings.Join(e.Env, "\x00"))
+}
+
+// envLine matches environment variable assignments
+var envLine = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// ParseTab reads a crontab, where every line is a comment starting with #, an environment variable assignment or a job
+// Environment variables such as PATH=/bin:/usr/bin are set for the jobs below them
+// Jobs consist of a schedule, any number of options and the command, for example:
+//
+//	*/15 * * * * jitter=5m timeout=10m /usr/local/bin/backup --incremental
+//	@daily splay=10% find /tmp -mtime +7 -delete
+//
+// The jitter option takes a duration, or a percentage of the time to the following run, splay is a synonym for it
+// The timeout option takes a duration after which the command is terminated
+func ParseTab(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var env []string
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if envLine.MatchString(text) {
+			name, value, _ := strings.Cut(text, "=")
+			env = append(env[:len(env):len(env)], strings.TrimSpace(name)+"="+unquote(strings.TrimSpace(value)))
+			continue
+		}
+
+		entry, err := parseEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		entry.Line = line
+		entry.Env = env
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read crontab: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseEntry parses a job line
+func parseEntry(text string) (Entry, error) {
+	var entry Entry
+
+	fields := 5
+	switch {
+	case strings.HasPrefix(text, "@every"):
+		fields = 2
+	case strings.HasPrefix(text, "@"):
+		fields = 1
+	}
+
+	spec, rest := cutFields(text, fields)
+	schedule, err := Parse(spec)
+	if err != nil {
+		return entry, fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	entry.Spec = spec
+	entry.Schedule = schedule
+
+	for {
+		option, remaining := cutFields(rest, 1)
+		name, value, _ := strings.Cut(option, "=")
+
+		switch name {
+		case "jitter", "splay":
+			if percent, ok := strings.CutSuffix(value, "%"); ok {
+				splay, err := strconv.ParseFloat(percent, 64)
+				if err != nil || splay < 0 || splay > 100 {
+					return entry, fmt.Errorf("invalid %s: %q", name, value)
+				}
+
+				entry.Splay = splay / 100
+			} else if entry.Jitter, err = time.ParseDuration(value); err != nil || entry.Jitter < 0 {
+				return entry, fmt.Errorf("invalid %s: %q", name, value)
+			}
+		case "timeout":
+			if entry.Timeout, err = time.ParseDuration(value); err != nil || entry.Timeout < 0 {
+				return entry, fmt.Errorf("invalid timeout: %q", value)
+			}
+		default:
+			// Not an option, so the command starts here
+			if rest == "" {
+				return entry, fmt.Errorf("missing command")
+			}
+
+			entry.Command = rest
+			return entry, nil
+		}
+
+		rest = remaining
+	}
+}
+
+// cutFields splits off the first n whitespace-separated fields, returning them joined by single spaces and the trimmed remainder
+func cutFields(text string, n int) (string, string) {
+	var fields []string
+	rest := strings.TrimSpace(text)
+	for len(fields) < n && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+
+		fields = append(fields, rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+	}
+
+	return strings.Join(fields, " "), rest
+}
+
+// unquote removes the quotes around an environment variable value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
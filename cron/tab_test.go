@@ -0,0 +1,59 @@
+package cron_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/cron"
+)
+
+func TestParseTab(t *testing.T) {
+	entries, err := cron.ParseTab(strings.NewReader(`
+# Backups
+PATH=/usr/local/bin:/usr/bin:/bin
+*/15 * * * * jitter=5m timeout=10m  backup --incremental   --quiet
+MAILTO=""
+@daily splay=10% find /tmp -mtime +7 -delete
+@every 30s TZ=UTC date
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []cron.Entry{
+		{Line: 4, Spec: "*/15 * * * *", Jitter: 5 * time.Minute, Timeout: 10 * time.Minute, Command: "backup --incremental   --quiet", Env: []string{"PATH=/usr/local/bin:/usr/bin:/bin"}},
+		{Line: 6, Spec: "@daily", Splay: 0.1, Command: "find /tmp -mtime +7 -delete", Env: []string{"PATH=/usr/local/bin:/usr/bin:/bin", "MAILTO="}},
+		{Line: 7, Spec: "@every 30s", Command: "TZ=UTC date", Env: []string{"PATH=/usr/local/bin:/usr/bin:/bin", "MAILTO="}},
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+
+	for i, entry := range entries {
+		if entry.Schedule == nil {
+			t.Errorf("entry %d: missing schedule", i)
+		}
+
+		entry.Schedule = nil
+		if entry.Key() != expected[i].Key() || entry.Line != expected[i].Line {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected[i], entry)
+		}
+	}
+}
+
+func TestParseTabErrors(t *testing.T) {
+	for _, tab := range []string{
+		"* * * * *",
+		"* * * * * jitter=5m",
+		"* * * * * jitter=soon true",
+		"* * * * * splay=150% true",
+		"* * * * * timeout=-1s true",
+		"* * * true",
+	} {
+		if _, err := cron.ParseTab(strings.NewReader(tab)); err == nil {
+			t.Errorf("%q: expected an error", tab)
+		}
+	}
+}
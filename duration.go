@@ -0,0 +1,27 @@
+package jitter
+
+import (
+	"time"
+)
+
+// The functions below draw from the runtime's per-thread random generators, so they are safe for concurrent use,
+// never lock and never allocate, which makes them suitable for hot paths such as computing cache TTLs
+// Use the methods of a seeded Source instead when the values need to be reproducible
+
+// Duration returns d with a random jitter in [0, j) added
+// If j is not positive, d is returned unchanged
+func Duration(d, j time.Duration) time.Duration {
+	return (*Source)(nil).Duration(d, j)
+}
+
+// Around returns d with a random jitter in [-j, j) added
+// If j is not positive, d is returned unchanged
+func Around(d, j time.Duration) time.Duration {
+	return (*Source)(nil).Around(d, j)
+}
+
+// Fraction returns d with a random jitter in [0, f*d) added, e.g. a fraction of 0.1 adds up to 10%
+// If the resulting jitter is not positive, d is returned unchanged
+func Fraction(d time.Duration, f float64) time.Duration {
+	return (*Source)(nil).Fraction(d, f)
+}
@@ -0,0 +1,96 @@
+package jitter_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+func TestDuration(t *testing.T) {
+	d := time.Second
+	j := 100 * time.Millisecond
+
+	for i := 0; i < 1000; i++ {
+		if got := jitter.Duration(d, j); got < d || got >= d+j {
+			t.Fatalf("Duration(%v, %v) = %v, out of range", d, j, got)
+		}
+	}
+
+	if got := jitter.Duration(d, 0); got != d {
+		t.Errorf("Duration(%v, 0) = %v, expected %v", d, got, d)
+	}
+}
+
+func TestAround(t *testing.T) {
+	d := time.Second
+	j := 100 * time.Millisecond
+
+	below := false
+	above := false
+	for i := 0; i < 1000; i++ {
+		got := jitter.Around(d, j)
+		if got < d-j || got >= d+j {
+			t.Fatalf("Around(%v, %v) = %v, out of range", d, j, got)
+		}
+
+		below = below || got < d
+		above = above || got > d
+	}
+
+	if !below || !above {
+		t.Errorf("Around(%v, %v) didn't spread to both sides", d, j)
+	}
+}
+
+func TestFraction(t *testing.T) {
+	d := time.Second
+
+	for i := 0; i < 1000; i++ {
+		if got := jitter.Fraction(d, 0.1); got < d || got >= d+d/10 {
+			t.Fatalf("Fraction(%v, 0.1) = %v, out of range", d, got)
+		}
+	}
+
+	if got := jitter.Fraction(d, -1); got != d {
+		t.Errorf("Fraction(%v, -1) = %v, expected %v", d, got, d)
+	}
+}
+
+func TestDurationAllocations(t *testing.T) {
+	allocs := testing.AllocsPerRun(1000, func() {
+		jitter.Duration(time.Second, time.Second)
+		jitter.Around(time.Second, time.Second)
+		jitter.Fraction(time.Second, 0.5)
+	})
+
+	if allocs != 0 {
+		t.Errorf("%v allocations per run, expected none", allocs)
+	}
+}
+
+// Run with -cpu 1,2,4,8 to compare how the lock-free functions and a shared locked generator scale
+func BenchmarkDuration(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			jitter.Duration(time.Minute, time.Second)
+		}
+	})
+}
+
+func BenchmarkDurationLocked(b *testing.B) {
+	var mu sync.Mutex
+	random := rand.New(rand.NewSource(1))
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			_ = time.Minute + time.Duration(random.Int63n(int64(time.Second)))
+			mu.Unlock()
+		}
+	})
+}
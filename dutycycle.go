@@ -0,0 +1,128 @@
+package jitter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Transition is a change of state of a DutyCycle
+type Transition struct {
+	On bool      // Whether the on state was entered
+	At time.Time // Time of the change
+}
+
+// DutyCycle alternates between an on and an off state with jittered durations, spending the duty fraction of the time on in the long run
+// If the receiver doesn't keep up, a pending transition is replaced by the newer one, so the last one received always matches the state
+type DutyCycle struct {
+	C <-chan Transition // Channel which the transitions are delivered on
+	c chan Transition   // Sending side of C, also drained when replacing a pending transition
+
+	clock  Clock   // Clock the duty cycle runs on
+	source *Source // Source of randomness for generating jitter
+
+	onMean    time.Duration // Mean duration of the on state
+	onJitter  time.Duration // Max jitter of the on state in either direction
+	offMean   time.Duration // Mean duration of the off state
+	offJitter time.Duration // Max jitter of the off state in either direction
+
+	mu      sync.Mutex // Protects everything below
+	on      bool       // Current state
+	timer   Timer      // Timer firing the next transition
+	stopped bool       // Whether the duty cycle is stopped
+}
+
+// NewDutyCycle returns a new duty cycle that starts in the off state
+// Each on state lasts duty*period and each off state (1-duty)*period, with a random jitter in [-jitter, jitter) scaled by the same fraction
+// added to each, so the jitter has to be at most the period to keep the durations positive
+func NewDutyCycle(period time.Duration, duty float64, jitter time.Duration, opts ...Option) *DutyCycle {
+	checkInterval("NewDutyCycle", period, jitter)
+
+	if duty <= 0 || duty >= 1 {
+		panic(fmt.Errorf("duty out of range for NewDutyCycle: %v", duty))
+	}
+
+	if jitter > period {
+		panic(fmt.Errorf("jitter greater than the period for NewDutyCycle: %d", int(jitter)))
+	}
+
+	o := newOptions(opts)
+
+	// Create a buffered channel for transitions
+	c := make(chan Transition, 1)
+	d := &DutyCycle{
+		C: c,
+		c: c,
+
+		clock:  o.clock,
+		source: o.source,
+
+		onMean:    time.Duration(float64(period) * duty),
+		onJitter:  time.Duration(float64(jitter) * duty),
+		offMean:   time.Duration(float64(period) * (1 - duty)),
+		offJitter: time.Duration(float64(jitter) * (1 - duty)),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.arm()
+
+	return d
+}
+
+// arm starts a timer for the end of the current state, d.mu must be held
+func (d *DutyCycle) arm() {
+	duration := d.source.Around(d.offMean, d.offJitter)
+	if d.on {
+		duration = d.source.Around(d.onMean, d.onJitter)
+	}
+
+	d.timer = d.clock.AfterFunc(duration, d.toggle)
+}
+
+// toggle switches to the other state and delivers the transition
+func (d *DutyCycle) toggle() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+
+	d.on = !d.on
+	transition := Transition{
+		On: d.on,
+		At: d.clock.Now(),
+	}
+
+	// Replace a pending transition the receiver hasn't picked up yet
+	select {
+	case <-d.c:
+	default:
+	}
+
+	select {
+	case d.c <- transition:
+	default:
+	}
+
+	d.arm()
+}
+
+// On reports whether the duty cycle is in the on state
+func (d *DutyCycle) On() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.on
+}
+
+// Stop will stop the duty cycle and return immediately, it stays in its current state and no transitions are sent after it returns
+func (d *DutyCycle) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stopped = true
+	d.timer.Stop()
+}
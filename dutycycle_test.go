@@ -0,0 +1,79 @@
+package jitter_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+func TestNewDutyCycle(t *testing.T) {
+	for name, f := range map[string]func(){
+		"non-positive period": func() { jitter.NewDutyCycle(0, 0.5, time.Second) },
+		"duty out of range":   func() { jitter.NewDutyCycle(time.Second, 1, time.Second) },
+		"jitter over period":  func() { jitter.NewDutyCycle(time.Second, 0.5, 2*time.Second) },
+	} {
+		t.Run("panics on "+name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("NewDutyCycle did not panic on %s", name)
+				}
+			}()
+
+			f()
+		})
+	}
+}
+
+func TestDutyCycle(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := jittertest.NewClock(start)
+	duty := jitter.NewDutyCycle(10*time.Second, 0.1, 5*time.Second, jitter.WithClock(clock), jitter.WithSource(jitter.NewSource(1)))
+	defer duty.Stop()
+
+	step := 100 * time.Millisecond
+	on := 0
+	steps := 100000
+	state := false
+
+	for i := 0; i < steps; i++ {
+		clock.Advance(step)
+
+		select {
+		case transition := <-duty.C:
+			if transition.On == state {
+				t.Fatalf("transition to the same state %v", state)
+			}
+
+			state = transition.On
+		default:
+		}
+
+		if duty.On() != state {
+			t.Fatalf("On() = %v, last transition was to %v", duty.On(), state)
+		}
+
+		if state {
+			on++
+		}
+	}
+
+	if fraction := float64(on) / float64(steps); math.Abs(fraction-0.1) > 0.01 {
+		t.Errorf("on %.3f of the time, expected 0.1", fraction)
+	}
+}
+
+func TestDutyCycleStop(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+	duty := jitter.NewDutyCycle(time.Second, 0.5, time.Second, jitter.WithClock(clock))
+	duty.Stop()
+
+	clock.Advance(time.Minute)
+	select {
+	case <-duty.C:
+		t.Error("transition after Stop")
+	default:
+	}
+}
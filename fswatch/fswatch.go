@@ -0,0 +1,316 @@
+// Package fswatch watches files and directories by polling them on a jittered interval
+// It's meant for filesystems where change notifications are unreliable, such as network filesystems
+package fswatch
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Op is the kind of change of a file
+type Op int
+
+const (
+	Create Op = iota + 1 // The file appeared
+	Modify               // The file's size, modification time, mode or contents changed
+	Delete               // The file disappeared
+)
+
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Modify:
+		return "modify"
+	case Delete:
+		return "delete"
+	default:
+		return fmt.Sprintf("Op(%d)", int(op))
+	}
+}
+
+// Event is a change of a file
+type Event struct {
+	Path string // Path of the file
+	Op   Op     // Kind of change
+}
+
+// Config configures a Watcher
+type Config struct {
+	Interval time.Duration // Interval of the polls
+	Jitter   time.Duration // Max jitter added to the interval
+	Debounce time.Duration // Time a file has to stay unchanged before its changes are emitted, coalesced into a single event
+	Hash     bool          // Whether to compare the contents of files too, for changes that keep the size and modification time
+}
+
+// Watcher polls a set of files and directories, directories are watched recursively
+type Watcher struct {
+	C      <-chan Event // Channel which the events are delivered on, closed by Close
+	Errors <-chan error // Channel which errors of the polls are delivered on, errors are discarded if the receiver doesn't keep up
+
+	c      chan Event
+	errors chan error
+
+	paths  []string
+	cfg    Config
+	ticker *jitter.Ticker
+
+	files   map[string]file     // Files seen by the last poll
+	pending map[string]*pending // Changes waiting for the debounce
+
+	done chan struct{}  // Closed by Close
+	wg   sync.WaitGroup // Waits for the polling goroutine
+	once sync.Once      // Makes Close idempotent
+}
+
+// file is the state of a file as seen by a poll
+type file struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+	hash    [sha256.Size]byte
+}
+
+// pending is a change waiting for the debounce
+type pending struct {
+	op      Op        // Coalesced kind of change
+	changed time.Time // Time of the last change
+}
+
+// New returns a new watcher for the given paths, which takes an initial snapshot and then polls on a jittered ticker
+// Errors of the initial snapshot are delivered on Errors, the files it couldn't read show up as created once they can be
+// The options are passed to the ticker
+func New(paths []string, cfg Config, opts ...jitter.Option) (*Watcher, error) {
+	if cfg.Interval <= 0 || cfg.Jitter <= 0 {
+		return nil, fmt.Errorf("non-positive interval or jitter: %v, %v", cfg.Interval, cfg.Jitter)
+	}
+
+	if cfg.Debounce < 0 {
+		return nil, fmt.Errorf("negative debounce: %v", cfg.Debounce)
+	}
+
+	c := make(chan Event)
+	errs := make(chan error, 1)
+	w := &Watcher{
+		C:      c,
+		Errors: errs,
+
+		c:      c,
+		errors: errs,
+
+		paths: paths,
+		cfg:   cfg,
+
+		pending: make(map[string]*pending),
+		done:    make(chan struct{}),
+	}
+
+	files, err := w.scan()
+	if err != nil {
+		w.report(err)
+	}
+
+	w.files = files
+
+	w.ticker = jitter.NewTicker(cfg.Interval, cfg.Jitter, opts...)
+	w.wg.Add(1)
+	go w.run()
+
+	return w, nil
+}
+
+// Close stops the watcher and closes its channels
+func (w *Watcher) Close() {
+	w.once.Do(func() {
+		w.ticker.Stop()
+		close(w.done)
+		w.wg.Wait()
+		close(w.c)
+		close(w.errors)
+	})
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case now := <-w.ticker.C:
+			if !w.poll(now) {
+				return
+			}
+		}
+	}
+}
+
+// poll compares the files to the previous poll and emits the changes that passed the debounce
+// It returns false if the watcher was closed while emitting
+func (w *Watcher) poll(now time.Time) bool {
+	files, err := w.scan()
+	if err != nil {
+		w.report(err)
+	}
+
+	for path, f := range files {
+		old, ok := w.files[path]
+		switch {
+		case !ok:
+			w.change(path, Create, now)
+		case old != f:
+			w.change(path, Modify, now)
+		}
+	}
+
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			w.change(path, Delete, now)
+		}
+	}
+
+	w.files = files
+
+	for path, p := range w.pending {
+		if now.Sub(p.changed) < w.cfg.Debounce {
+			continue
+		}
+
+		delete(w.pending, path)
+
+		select {
+		case <-w.done:
+			return false
+		case w.c <- Event{Path: path, Op: p.op}:
+		}
+	}
+
+	return true
+}
+
+// report delivers an error on Errors, or discards it if the receiver doesn't keep up
+func (w *Watcher) report(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// change coalesces a change of a file with its pending one
+func (w *Watcher) change(path string, op Op, now time.Time) {
+	p := w.pending[path]
+	if p == nil {
+		w.pending[path] = &pending{op: op, changed: now}
+		return
+	}
+
+	p.changed = now
+	switch {
+	case p.op == Create && op == Delete:
+		delete(w.pending, path) // The file came and went
+	case p.op == Create:
+		// Still a new file
+	case p.op == Delete && op == Create:
+		p.op = Modify // The file was replaced
+	default:
+		p.op = op
+	}
+}
+
+// scan returns the state of all watched files
+// Files that can't be read keep their previous state, so errors don't show up as deletions
+// Missing paths aren't an error, as they may be created later
+func (w *Watcher) scan() (map[string]file, error) {
+	files := make(map[string]file)
+
+	var errs []error
+	for _, root := range w.paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			if err != nil {
+				errs = append(errs, err)
+				w.carry(files, path)
+				return nil
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			f, err := w.stat(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // Deleted while walking
+			}
+
+			if err != nil {
+				errs = append(errs, err)
+				w.carry(files, path)
+				return nil
+			}
+
+			files[path] = f
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return files, errors.Join(errs...)
+}
+
+// carry copies the previous state of the path, and of the files below it if it's a directory
+func (w *Watcher) carry(files map[string]file, path string) {
+	prefix := path + string(filepath.Separator)
+	for p, f := range w.files {
+		if p == path || strings.HasPrefix(p, prefix) {
+			files[p] = f
+		}
+	}
+}
+
+// stat returns the state of a file
+func (w *Watcher) stat(path string) (file, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return file{}, err
+	}
+
+	f := file{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		mode:    info.Mode(),
+	}
+
+	if !w.cfg.Hash {
+		return f, nil
+	}
+
+	r, err := os.Open(path)
+	if err != nil {
+		return file{}, err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return file{}, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	copy(f.hash[:], h.Sum(nil))
+
+	return f, nil
+}
@@ -0,0 +1,182 @@
+package fswatch_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/fswatch"
+)
+
+// expect waits for the next event and checks it
+func expect(t *testing.T, w *fswatch.Watcher, path string, op fswatch.Op) {
+	t.Helper()
+
+	select {
+	case event := <-w.C:
+		if event.Path != path || event.Op != op {
+			t.Fatalf("got %v of %s, expected %v of %s", event.Op, event.Path, op, path)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no %v of %s", op, path)
+	}
+}
+
+// expectNone checks that there are no events for a while
+func expectNone(t *testing.T, w *fswatch.Watcher) {
+	t.Helper()
+
+	select {
+	case event := <-w.C:
+		t.Fatalf("unexpected %v of %s", event.Op, event.Path)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func write(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.conf")
+	write(t, existing, "a")
+
+	w, err := fswatch.New([]string{dir}, fswatch.Config{
+		Interval: 10 * time.Millisecond,
+		Jitter:   5 * time.Millisecond,
+		Hash:     true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer w.Close()
+
+	expectNone(t, w)
+
+	path := filepath.Join(dir, "sub", "app.conf")
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	write(t, path, "a")
+	expect(t, w, path, fswatch.Create)
+
+	// Same size, the modification time may not change on coarse filesystems, but the hash does
+	write(t, path, "b")
+	expect(t, w, path, fswatch.Modify)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove %s: %v", path, err)
+	}
+	expect(t, w, path, fswatch.Delete)
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.conf")
+
+	w, err := fswatch.New([]string{path}, fswatch.Config{
+		Interval: 5 * time.Millisecond,
+		Jitter:   time.Millisecond,
+		Debounce: 100 * time.Millisecond,
+		Hash:     true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer w.Close()
+
+	// Changes in quick succession are coalesced into the creation
+	for _, content := range []string{"a", "ab", "abc"} {
+		write(t, path, content)
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	expect(t, w, path, fswatch.Create)
+	expectNone(t, w)
+
+	// A file that comes and goes within the debounce isn't reported
+	other := filepath.Join(dir, "other.conf")
+	w2, err := fswatch.New([]string{other}, fswatch.Config{
+		Interval: 5 * time.Millisecond,
+		Jitter:   time.Millisecond,
+		Debounce: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer w2.Close()
+
+	write(t, other, "a")
+	time.Sleep(20 * time.Millisecond)
+	if err := os.Remove(other); err != nil {
+		t.Fatalf("failed to remove %s: %v", other, err)
+	}
+
+	expectNone(t, w2)
+}
+
+func TestWatcherClose(t *testing.T) {
+	w, err := fswatch.New([]string{t.TempDir()}, fswatch.Config{
+		Interval: time.Millisecond,
+		Jitter:   time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+
+	w.Close()
+	w.Close() // Closing again has no effect
+
+	if _, ok := <-w.C; ok {
+		t.Error("event channel not closed")
+	}
+}
+
+func TestWatcherInvalid(t *testing.T) {
+	for _, cfg := range []fswatch.Config{
+		{Jitter: time.Millisecond},
+		{Interval: time.Millisecond},
+		{Interval: time.Millisecond, Jitter: -time.Millisecond},
+		{Interval: time.Millisecond, Jitter: time.Millisecond, Debounce: -time.Millisecond},
+	} {
+		if _, err := fswatch.New([]string{t.TempDir()}, cfg); err == nil {
+			t.Errorf("no error for %+v", cfg)
+		}
+	}
+}
+
+func TestWatcherInitialError(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	write(t, file, "a")
+
+	// A path below a file can't be walked, which doesn't keep the other paths from being watched
+	w, err := fswatch.New([]string{filepath.Join(file, "sub"), dir}, fswatch.Config{
+		Interval: 10 * time.Millisecond,
+		Jitter:   5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer w.Close()
+
+	select {
+	case err := <-w.Errors:
+		if err == nil {
+			t.Error("nil error delivered")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error delivered")
+	}
+
+	path := filepath.Join(dir, "app.conf")
+	write(t, path, "a")
+	expect(t, w, path, fswatch.Create)
+}
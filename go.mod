@@ -1,3 +1,3 @@
 module github.com/gerifield/jitter
 
-go 1.12
+go 1.22
@@ -0,0 +1,169 @@
+// Package gossip picks random peers for gossip and anti-entropy rounds on a jittered schedule
+// Every round picks a few peers from the current membership, preferring the ones that weren't picked in the last rounds,
+// and runs an exchange with each of them, limiting how many run at once
+package gossip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Config configures a Gossiper
+type Config struct {
+	Interval time.Duration // Interval of the rounds
+	Jitter   time.Duration // Max jitter added to the interval
+
+	Fanout      int // Number of peers picked per round
+	Cooldown    int // Number of rounds a picked peer is avoided for, as long as there are enough other peers
+	MaxInFlight int // Max number of exchanges running at once, Fanout when zero
+
+	Exchange func(ctx context.Context, peer string) error // Function called to exchange state with a peer
+	Source   *jitter.Source                               // Source of randomness for picking peers
+}
+
+// Gossiper runs rounds of exchanges with random peers
+type Gossiper struct {
+	cfg Config
+
+	mu     sync.Mutex     // Protects everything below
+	peers  []string       // Current membership, sorted
+	picked map[string]int // Round each peer was last picked in
+	round  int            // Number of the last round
+}
+
+// New returns a new gossiper with no peers
+func New(cfg Config) *Gossiper {
+	if cfg.Fanout <= 0 {
+		panic(fmt.Errorf("non-positive fanout for New: %d", cfg.Fanout))
+	}
+
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = cfg.Fanout
+	}
+
+	return &Gossiper{
+		cfg:    cfg,
+		picked: make(map[string]int),
+	}
+}
+
+// SetPeers replaces the membership with the given peers
+func (g *Gossiper) SetPeers(peers []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.peers = append(g.peers[:0], peers...)
+	sort.Strings(g.peers)
+
+	// Forget the peers that left
+	members := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		members[peer] = true
+	}
+
+	for peer := range g.picked {
+		if !members[peer] {
+			delete(g.picked, peer)
+		}
+	}
+}
+
+// Pick starts a new round and returns the peers picked for it, without running any exchanges
+// Peers picked within the last Cooldown rounds are only picked when there aren't enough others, least recently picked first
+func (g *Gossiper) Pick() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.round++
+
+	var fresh, recent []string
+	for _, peer := range g.peers {
+		if last, ok := g.picked[peer]; ok && g.round-last <= g.cfg.Cooldown {
+			recent = append(recent, peer)
+		} else {
+			fresh = append(fresh, peer)
+		}
+	}
+
+	picked := g.sample(fresh, g.cfg.Fanout)
+	if len(picked) < g.cfg.Fanout {
+		sort.SliceStable(recent, func(i, j int) bool {
+			return g.picked[recent[i]] < g.picked[recent[j]]
+		})
+
+		picked = append(picked, recent[:min(len(recent), g.cfg.Fanout-len(picked))]...)
+	}
+
+	for _, peer := range picked {
+		g.picked[peer] = g.round
+	}
+
+	return picked
+}
+
+// sample returns up to n random peers of the candidates, reordering the candidates
+func (g *Gossiper) sample(candidates []string, n int) []string {
+	n = min(n, len(candidates))
+
+	// Partial Fisher-Yates shuffle
+	for i := 0; i < n; i++ {
+		j := i + int(g.cfg.Source.Int64N(int64(len(candidates)-i)))
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+
+	return candidates[:n:n]
+}
+
+// Round picks the peers for a new round and exchanges with them, returning the picked peers and the errors of the exchanges
+func (g *Gossiper) Round(ctx context.Context) ([]string, error) {
+	peers := g.Pick()
+	errs := make([]error, len(peers))
+
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, g.cfg.MaxInFlight)
+	for i, peer := range peers {
+		inFlight <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			if err := g.cfg.Exchange(ctx, peer); err != nil {
+				errs[i] = fmt.Errorf("exchange with %s failed: %w", peer, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return peers, errors.Join(errs...)
+}
+
+// Run runs rounds on a jittered ticker until the context is cancelled, the options are passed to the ticker
+// Rounds don't overlap, ticks that arrive while a round is running are discarded, so the next round starts on the following tick
+// Errors of the exchanges are dropped, so the exchange function has to report them itself
+func (g *Gossiper) Run(ctx context.Context, opts ...jitter.Option) {
+	ticker := jitter.NewTicker(g.cfg.Interval, g.cfg.Jitter, opts...)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = g.Round(ctx)
+
+			// Discard the tick kept while the round was running, so a long round isn't followed by another right away
+			select {
+			case <-ticker.C:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package gossip_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/gossip"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+func peers(n int) []string {
+	var peers []string
+	for i := 0; i < n; i++ {
+		peers = append(peers, fmt.Sprintf("peer-%d", i))
+	}
+
+	return peers
+}
+
+func TestPickAvoidsRecentPeers(t *testing.T) {
+	g := gossip.New(gossip.Config{
+		Fanout:   3,
+		Cooldown: 2,
+		Source:   jitter.NewSource(1),
+	})
+	g.SetPeers(peers(10))
+
+	var last [][]string
+	for round := 0; round < 100; round++ {
+		picked := g.Pick()
+		if len(picked) != 3 {
+			t.Fatalf("picked %v, expected 3 peers", picked)
+		}
+
+		seen := make(map[string]bool)
+		for _, peer := range picked {
+			if seen[peer] {
+				t.Fatalf("picked %s twice in round %d", peer, round)
+			}
+
+			seen[peer] = true
+		}
+
+		// There are enough peers to avoid the ones picked in the last 2 rounds
+		for _, previous := range last {
+			for _, peer := range previous {
+				if seen[peer] {
+					t.Fatalf("picked %s again in round %d within the cooldown", peer, round)
+				}
+			}
+		}
+
+		last = append(last, picked)
+		if len(last) > 2 {
+			last = last[1:]
+		}
+	}
+}
+
+func TestPickFewPeers(t *testing.T) {
+	g := gossip.New(gossip.Config{
+		Fanout:   3,
+		Cooldown: 5,
+	})
+
+	g.SetPeers(peers(2))
+	if picked := g.Pick(); len(picked) != 2 {
+		t.Errorf("picked %v, expected both peers", picked)
+	}
+
+	// Both peers are in their cooldown, but there are no others
+	if picked := g.Pick(); len(picked) != 2 {
+		t.Errorf("picked %v, expected both peers", picked)
+	}
+
+	g.SetPeers(nil)
+	if picked := g.Pick(); len(picked) != 0 {
+		t.Errorf("picked %v without peers", picked)
+	}
+}
+
+func TestRoundLimitsInFlight(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	exchange := func(ctx context.Context, peer string) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		if peer == "peer-0" {
+			return errors.New("unreachable")
+		}
+
+		return nil
+	}
+
+	g := gossip.New(gossip.Config{
+		Fanout:      5,
+		MaxInFlight: 2,
+		Exchange:    exchange,
+	})
+	g.SetPeers(peers(5))
+
+	picked, err := g.Round(context.Background())
+	if len(picked) != 5 {
+		t.Errorf("picked %v, expected all 5 peers", picked)
+	}
+
+	if err == nil {
+		t.Error("no error for a failed exchange")
+	}
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d exchanges in flight, expected at most 2", p)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	exchanged := make(map[string]int)
+	exchange := func(ctx context.Context, peer string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		exchanged[peer]++
+		return nil
+	}
+
+	g := gossip.New(gossip.Config{
+		Interval: 5 * time.Millisecond,
+		Jitter:   time.Millisecond,
+		Fanout:   1,
+		Cooldown: 1,
+		Exchange: exchange,
+	})
+	g.SetPeers(peers(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	g.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// With a cooldown of a round, the two peers take turns
+	if exchanged["peer-0"] == 0 || exchanged["peer-1"] == 0 {
+		t.Errorf("exchanges %v, expected both peers", exchanged)
+	}
+}
+
+func TestRunDiscardsTicksDuringRound(t *testing.T) {
+	var rounds atomic.Int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	exchange := func(ctx context.Context, peer string) error {
+		rounds.Add(1)
+		started <- struct{}{}
+		<-release
+
+		return nil
+	}
+
+	g := gossip.New(gossip.Config{
+		Interval: time.Minute,
+		Jitter:   time.Second,
+		Fanout:   1,
+		Exchange: exchange,
+	})
+	g.SetPeers(peers(2))
+
+	clock := jittertest.NewClock(time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.Run(ctx, jitter.WithClock(clock))
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for clock.Pending() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("ticker not started")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(time.Minute + time.Second)
+	<-started
+
+	// Ticks during the round don't start another one once it's done
+	clock.Advance(3 * time.Minute)
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+
+	if n := rounds.Load(); n != 1 {
+		t.Errorf("%d rounds, expected a single one until the next tick", n)
+	}
+}
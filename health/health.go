@@ -0,0 +1,228 @@
+// Package health actively checks the health of endpoints on jittered intervals
+// Probes are spread over the interval with a jitter.Spreader, and an endpoint only changes its status
+// after a number of consecutive probes agree, to avoid flapping on a single failure or success
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Status is the health status of an endpoint
+type Status int
+
+const (
+	Unknown   Status = iota // Not enough probes yet to decide
+	Healthy                 // Passed Rise consecutive probes
+	Unhealthy               // Failed Fall consecutive probes
+)
+
+func (s Status) String() string {
+	switch s {
+	case Healthy:
+		return "healthy"
+	case Unhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+// Probe checks an endpoint, returning an error if it's unhealthy
+type Probe func(ctx context.Context) error
+
+// Change is a change of the status of an endpoint
+type Change struct {
+	Name   string    // Name of the endpoint
+	Status Status    // New status
+	Err    error     // Error of the last probe, nil when healthy
+	At     time.Time // Time of the change
+}
+
+// Config configures a Checker
+type Config struct {
+	Interval time.Duration // Interval at which every endpoint is probed
+	Jitter   time.Duration // Max jitter added to every probe
+	Timeout  time.Duration // Max duration of a probe, the interval when zero
+	Rise     int           // Consecutive successful probes needed to become healthy, 1 when zero
+	Fall     int           // Consecutive failed probes needed to become unhealthy, 1 when zero
+
+	OnChange func(Change) // Called on every change, nil for none
+	Buffer   int          // Capacity of the channel changes are delivered on, changes that don't fit are discarded from it
+
+	Clock  jitter.Clock   // Clock the checker runs on, jitter.RealClock when nil
+	Source *jitter.Source // Source of randomness for generating jitter
+}
+
+// Checker probes a set of endpoints and tracks their health
+type Checker struct {
+	C <-chan Change // Channel which the changes are delivered on
+	c chan Change   // Sending side of C
+
+	cfg      Config
+	spreader *jitter.Spreader
+
+	mu        sync.Mutex           // Protects endpoints
+	endpoints map[string]*endpoint // Endpoints being probed
+}
+
+// endpoint is the state of an endpoint of a Checker
+type endpoint struct {
+	probe     Probe  // Probe checking the endpoint
+	status    Status // Current status
+	successes int    // Consecutive successful probes
+	failures  int    // Consecutive failed probes
+}
+
+// NewChecker returns a new checker with no endpoints, it panics on an invalid interval or jitter like jitter.NewSpreader
+func NewChecker(cfg Config) *Checker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = cfg.Interval
+	}
+
+	if cfg.Rise <= 0 {
+		cfg.Rise = 1
+	}
+
+	if cfg.Fall <= 0 {
+		cfg.Fall = 1
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = jitter.RealClock
+	}
+
+	c := make(chan Change, cfg.Buffer)
+	checker := &Checker{
+		C: c,
+		c: c,
+
+		cfg: cfg,
+
+		endpoints: make(map[string]*endpoint),
+	}
+
+	checker.spreader = jitter.NewSpreader(cfg.Interval, cfg.Jitter, checker.check, jitter.WithClock(cfg.Clock), jitter.WithSource(cfg.Source))
+
+	return checker
+}
+
+// Add starts probing an endpoint with an unknown status, replacing the probe if the endpoint already exists
+func (c *Checker) Add(name string, probe Probe) {
+	c.mu.Lock()
+	if e := c.endpoints[name]; e != nil {
+		e.probe = probe
+	} else {
+		c.endpoints[name] = &endpoint{probe: probe}
+	}
+	c.mu.Unlock()
+
+	c.spreader.Add(name)
+}
+
+// Remove stops probing an endpoint and forgets its status
+func (c *Checker) Remove(name string) {
+	c.spreader.Remove(name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.endpoints, name)
+}
+
+// Status returns the current status of an endpoint, Unknown for endpoints that aren't being probed
+func (c *Checker) Status(name string) Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e := c.endpoints[name]; e != nil {
+		return e.status
+	}
+
+	return Unknown
+}
+
+// Stop stops probing all endpoints and returns immediately
+func (c *Checker) Stop() {
+	c.spreader.Stop()
+}
+
+// check runs the probe of an endpoint and applies the thresholds to the result
+func (c *Checker) check(ctx context.Context, name string) {
+	// Add may replace the probe while this one runs, so it's read under the lock
+	c.mu.Lock()
+	e := c.endpoints[name]
+	var probe Probe
+	if e != nil {
+		probe = e.probe
+	}
+	c.mu.Unlock()
+
+	if e == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	err := probe(ctx)
+	if ctx.Err() != nil && err == nil {
+		err = fmt.Errorf("probe timed out: %w", ctx.Err())
+	}
+
+	c.mu.Lock()
+	change, changed := c.update(name, e, err)
+	c.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if c.cfg.OnChange != nil {
+		c.cfg.OnChange(change)
+	}
+
+	select {
+	case c.c <- change:
+	default: // Discard the change if the receiver doesn't keep up
+	}
+}
+
+// update applies the result of a probe to an endpoint and returns the change of its status, if any, c.mu must be held
+func (c *Checker) update(name string, e *endpoint, err error) (Change, bool) {
+	if c.endpoints[name] != e {
+		return Change{}, false // The endpoint was removed while probing
+	}
+
+	status := e.status
+	if err == nil {
+		e.successes++
+		e.failures = 0
+		if e.successes >= c.cfg.Rise {
+			status = Healthy
+		}
+	} else {
+		e.failures++
+		e.successes = 0
+		if e.failures >= c.cfg.Fall {
+			status = Unhealthy
+		}
+	}
+
+	if status == e.status {
+		return Change{}, false
+	}
+
+	e.status = status
+
+	return Change{
+		Name:   name,
+		Status: status,
+		Err:    err,
+		At:     c.cfg.Clock.Now(),
+	}, true
+}
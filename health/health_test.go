@@ -0,0 +1,175 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/health"
+)
+
+func config() health.Config {
+	return health.Config{
+		Interval: 10 * time.Millisecond,
+		Jitter:   5 * time.Millisecond,
+		Rise:     2,
+		Fall:     3,
+		Buffer:   10,
+	}
+}
+
+// expect waits for the next change and checks it
+func expect(t *testing.T, checker *health.Checker, name string, status health.Status) {
+	t.Helper()
+
+	select {
+	case change := <-checker.C:
+		if change.Name != name || change.Status != status {
+			t.Fatalf("got change of %s to %v, expected %s to %v", change.Name, change.Status, name, status)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no change of %s to %v", name, status)
+	}
+}
+
+func TestCheckerHTTP(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var changes atomic.Int32
+	cfg := config()
+	cfg.OnChange = func(health.Change) {
+		changes.Add(1)
+	}
+
+	checker := health.NewChecker(cfg)
+	defer checker.Stop()
+
+	checker.Add("http", health.HTTP(server.Client(), server.URL))
+	expect(t, checker, "http", health.Healthy)
+
+	healthy.Store(false)
+	expect(t, checker, "http", health.Unhealthy)
+
+	healthy.Store(true)
+	expect(t, checker, "http", health.Healthy)
+
+	if checker.Status("http") != health.Healthy {
+		t.Errorf("Status() = %v, expected %v", checker.Status("http"), health.Healthy)
+	}
+
+	if n := changes.Load(); n != 3 {
+		t.Errorf("OnChange called %d times, expected 3", n)
+	}
+}
+
+func TestCheckerTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	checker := health.NewChecker(config())
+	defer checker.Stop()
+
+	checker.Add("tcp", health.TCP(listener.Addr().String()))
+	expect(t, checker, "tcp", health.Healthy)
+
+	listener.Close()
+	expect(t, checker, "tcp", health.Unhealthy)
+}
+
+func TestCheckerThresholds(t *testing.T) {
+	var calls atomic.Int32
+	probe := func(ctx context.Context) error {
+		// Fail every other probe, which never reaches either threshold after the first success
+		if calls.Add(1)%2 == 0 {
+			return errors.New("flapping")
+		}
+
+		return nil
+	}
+
+	cfg := config()
+	cfg.Rise = 1
+	checker := health.NewChecker(cfg)
+	defer checker.Stop()
+
+	checker.Add("flapping", probe)
+	expect(t, checker, "flapping", health.Healthy)
+
+	select {
+	case change := <-checker.C:
+		t.Errorf("unexpected change of a flapping endpoint: %+v", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	probe := func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+
+	cfg := config()
+	cfg.Timeout = time.Millisecond
+	cfg.Fall = 1
+	checker := health.NewChecker(cfg)
+	defer checker.Stop()
+
+	checker.Add("slow", probe)
+	expect(t, checker, "slow", health.Unhealthy)
+
+	checker.Remove("slow")
+	if checker.Status("slow") != health.Unknown {
+		t.Errorf("Status() = %v for a removed endpoint, expected %v", checker.Status("slow"), health.Unknown)
+	}
+}
+
+func TestCheckerReplaceProbe(t *testing.T) {
+	var failing atomic.Int32
+	fail := func(ctx context.Context) error {
+		failing.Add(1)
+		time.Sleep(time.Millisecond) // Keep the probe running while it's replaced
+		return errors.New("down")
+	}
+
+	succeed := func(ctx context.Context) error {
+		return nil
+	}
+
+	cfg := config()
+	cfg.Interval = time.Millisecond
+	cfg.Jitter = time.Millisecond
+	cfg.Fall = 1
+	checker := health.NewChecker(cfg)
+	defer checker.Stop()
+
+	checker.Add("replaced", fail)
+	expect(t, checker, "replaced", health.Unhealthy)
+
+	// Replace the probe while probes are running, which the race detector checks
+	for i := 0; i < 50; i++ {
+		checker.Add("replaced", fail)
+		time.Sleep(100 * time.Microsecond)
+	}
+
+	checker.Add("replaced", succeed)
+	expect(t, checker, "replaced", health.Healthy)
+
+	if failing.Load() < 2 {
+		t.Errorf("expected the failing probe to run repeatedly, ran %d times", failing.Load())
+	}
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// HTTP returns a probe that sends a GET request to the url, treating 2xx and 3xx responses as healthy
+// The default client is used if client is nil
+func HTTP(client *http.Client, url string) Probe {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Drain the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+
+		return nil
+	}
+}
+
+// TCP returns a probe that opens a TCP connection to the address, treating a successful connection as healthy
+func TCP(address string) Probe {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+
+		return conn.Close()
+	}
+}
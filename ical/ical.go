@@ -0,0 +1,344 @@
+// Package ical reads blackout periods such as change freezes and holidays from iCalendar files
+// It understands VEVENT entries with DTSTART, DTEND or DURATION, EXDATE and simple RRULEs, see RFC 5545.
+// A Calendar is a jitter.Blackout, so it can be passed to jitter.SkipDuring and jitter.DeferDuring
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Calendar is a set of events read from an iCalendar file
+type Calendar struct {
+	Events []*Event
+}
+
+// Event is a possibly recurring event
+type Event struct {
+	Summary  string        // Summary of the event
+	Start    time.Time     // Start of the first occurrence
+	Duration time.Duration // Duration of every occurrence
+	Rule     *Rule         // Recurrence rule, nil for a single occurrence
+	Exclude  []time.Time   // Starts of occurrences excluded from the rule
+}
+
+// property is a content line of an iCalendar file
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the events of an iCalendar file, floating times and dates are interpreted in loc
+func Parse(r io.Reader, loc *time.Location) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &Calendar{}
+
+	var event *Event
+	var end time.Time
+	var hasDuration bool
+	var components []string // Components that are open, innermost last
+	for i, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch {
+		case p.name == "BEGIN":
+			components = append(components, strings.ToUpper(p.value))
+			if components[len(components)-1] == "VEVENT" {
+				event = &Event{}
+				end = time.Time{}
+				hasDuration = false
+			}
+		case p.name == "END":
+			if len(components) == 0 || components[len(components)-1] != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("line %d: END:%s without BEGIN:%s", i+1, p.value, p.value)
+			}
+
+			components = components[:len(components)-1]
+			if strings.ToUpper(p.value) != "VEVENT" {
+				continue
+			}
+
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q without DTSTART", i+1, event.Summary)
+			}
+
+			if !hasDuration && !end.IsZero() {
+				event.Duration = end.Sub(event.Start)
+			}
+
+			cal.Events = append(cal.Events, event)
+			event = nil
+		case len(components) == 0 || components[len(components)-1] != "VEVENT":
+			// Only the properties of events are needed, not those of calendars or of components nested in events such as alarms
+		case p.name == "SUMMARY":
+			event.Summary = unescape(p.value)
+		case p.name == "DTSTART":
+			start, date, err := parseTime(p, loc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTSTART: %w", i+1, err)
+			}
+
+			event.Start = start
+			if date && end.IsZero() && !hasDuration {
+				event.Duration = 24 * time.Hour // All-day events last a day unless stated otherwise
+			}
+		case p.name == "DTEND":
+			if end, _, err = parseTime(p, loc); err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTEND: %w", i+1, err)
+			}
+		case p.name == "DURATION":
+			if event.Duration, err = parseDuration(p.value); err != nil {
+				return nil, fmt.Errorf("line %d: invalid DURATION: %w", i+1, err)
+			}
+
+			hasDuration = true
+		case p.name == "RRULE":
+			if event.Rule, err = parseRule(p.value, loc); err != nil {
+				return nil, fmt.Errorf("line %d: invalid RRULE: %w", i+1, err)
+			}
+		case p.name == "EXDATE":
+			for _, value := range strings.Split(p.value, ",") {
+				exclude, _, err := parseTime(property{params: p.params, value: value}, loc)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid EXDATE: %w", i+1, err)
+				}
+
+				event.Exclude = append(event.Exclude, exclude)
+			}
+		}
+	}
+
+	if event != nil {
+		return nil, fmt.Errorf("event %q not terminated", event.Summary)
+	}
+
+	if len(components) > 0 {
+		return nil, fmt.Errorf("%s not terminated", components[len(components)-1])
+	}
+
+	return cal, nil
+}
+
+// unfold reads the content lines, joining the ones that were folded onto several lines
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+
+	return lines, nil
+}
+
+// parseProperty parses a content line of the form NAME;PARAM=VALUE:VALUE
+func parseProperty(line string) (property, error) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return property{}, fmt.Errorf("missing value: %q", line)
+	}
+
+	parts := strings.Split(head, ";")
+	p := property{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  value,
+	}
+
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+
+	return p, nil
+}
+
+// parseTime parses a date or date-time value and reports whether it was a date
+func parseTime(p property, loc *time.Location) (time.Time, bool, error) {
+	if tzid := p.params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown time zone: %w", err)
+		}
+	}
+
+	value := p.value
+	switch {
+	case p.params["VALUE"] == "DATE" || len(value) == len("20060102"):
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	case strings.HasSuffix(value, "Z"):
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	default:
+		t, err := time.ParseInLocation("20060102T150405", value, loc)
+		return t, false, err
+	}
+}
+
+// parseDuration parses a duration such as P1D, PT1H30M or P2W, nominal days are taken to be 24 hours
+func parseDuration(value string) (time.Duration, error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("malformed duration: %q", value)
+	}
+
+	var d time.Duration
+	inTime := false
+	n := 0
+	digits := false
+	for _, c := range value[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			n = n*10 + int(c-'0')
+			digits = true
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+
+		if !digits {
+			return 0, fmt.Errorf("malformed duration: %q", value)
+		}
+
+		unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+		if inTime {
+			unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		}
+
+		u, ok := unit[c]
+		if !ok {
+			return 0, fmt.Errorf("malformed duration: %q", value)
+		}
+
+		d += time.Duration(n) * u
+		n = 0
+		digits = false
+	}
+
+	if digits {
+		return 0, fmt.Errorf("malformed duration: %q", value)
+	}
+
+	if negative {
+		d = -d
+	}
+
+	return d, nil
+}
+
+// unescape removes the escaping of a text value
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// Blocked returns the latest end of the occurrences containing t, or false if none of them does
+func (c *Calendar) Blocked(t time.Time) (time.Time, bool) {
+	var end time.Time
+	for _, event := range c.Events {
+		event.each(t, func(start time.Time) bool {
+			if occurrenceEnd := start.Add(event.Duration); start.Compare(t) <= 0 && occurrenceEnd.After(t) && occurrenceEnd.After(end) {
+				end = occurrenceEnd
+			}
+
+			return true
+		})
+	}
+
+	return end, !end.IsZero()
+}
+
+// Windows returns the merged occurrences of all events that overlap the period from start to end
+func (c *Calendar) Windows(start, end time.Time) jitter.Windows {
+	var windows jitter.Windows
+	for _, event := range c.Events {
+		event.each(end, func(occurrence time.Time) bool {
+			window := jitter.Window{Start: occurrence, End: occurrence.Add(event.Duration)}
+			if window.End.After(start) && window.Start.Before(end) {
+				windows = append(windows, window)
+			}
+
+			return true
+		})
+	}
+
+	return windows.Merge()
+}
+
+// Occurrences returns the starts of the occurrences of the event up to and including until
+func (e *Event) Occurrences(until time.Time) []time.Time {
+	var starts []time.Time
+	e.each(until, func(start time.Time) bool {
+		starts = append(starts, start)
+		return true
+	})
+
+	return starts
+}
+
+// each calls f with the starts of the occurrences of the event in order, up to and including until or until f returns false
+func (e *Event) each(until time.Time, f func(start time.Time) bool) {
+	emit := func(start time.Time) bool {
+		for _, exclude := range e.Exclude {
+			if start.Equal(exclude) {
+				return true
+			}
+		}
+
+		return f(start)
+	}
+
+	if e.Rule == nil {
+		if !e.Start.After(until) {
+			emit(e.Start)
+		}
+
+		return
+	}
+
+	e.Rule.each(e.Start, until, emit)
+}
+
+// sortedWeekdays returns the weekdays sorted by their offset from Monday
+func sortedWeekdays(days []time.Weekday) []time.Weekday {
+	sorted := append([]time.Weekday(nil), days...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return fromMonday(sorted[i]) < fromMonday(sorted[j])
+	})
+
+	return sorted
+}
+
+// fromMonday returns the number of days from Monday to the weekday
+func fromMonday(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
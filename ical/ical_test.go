@@ -0,0 +1,279 @@
+package ical_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/ical"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+const holidays = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Year end\r\n" +
+	"  freeze\r\n" +
+	"DTSTART;VALUE=DATE:20261224\r\n" +
+	"DTEND;VALUE=DATE:20270102\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Release window\r\n" +
+	"DTSTART:20261001T220000Z\r\n" +
+	"DURATION:PT2H\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=TU,TH;COUNT=4\r\n" +
+	"EXDATE:20261006T220000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func TestParse(t *testing.T) {
+	cal, err := ical.Parse(strings.NewReader(holidays), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cal.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(cal.Events))
+	}
+
+	freeze := cal.Events[0]
+	if freeze.Summary != "Year end freeze" {
+		t.Errorf("unexpected summary: %q", freeze.Summary)
+	}
+
+	if !freeze.Start.Equal(date("2026-12-24T00:00:00Z")) || freeze.Duration != 9*24*time.Hour {
+		t.Errorf("unexpected freeze: %v for %v", freeze.Start, freeze.Duration)
+	}
+
+	expected := []time.Time{
+		date("2026-10-01T22:00:00Z"),
+		date("2026-10-08T22:00:00Z"),
+		date("2026-10-13T22:00:00Z"),
+	}
+
+	occurrences := cal.Events[1].Occurrences(date("2027-01-01T00:00:00Z"))
+	if len(occurrences) != len(expected) {
+		t.Fatalf("expected occurrences %v, got %v", expected, occurrences)
+	}
+
+	for i := range expected {
+		if !occurrences[i].Equal(expected[i]) {
+			t.Errorf("expected occurrences %v, got %v", expected, occurrences)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, event := range map[string]string{
+		"missing start":     "SUMMARY:x\n",
+		"bad date":          "DTSTART:2026-10-01\n",
+		"bad duration":      "DTSTART:20261001T000000Z\nDURATION:P1X\n",
+		"unsupported rule":  "DTSTART:20261001T000000Z\nRRULE:FREQ=MONTHLY;BYMONTHDAY=1\n",
+		"unknown frequency": "DTSTART:20261001T000000Z\nRRULE:FREQ=HOURLY\n",
+		"unknown time zone": "DTSTART;TZID=Nowhere/Atlantis:20261001T000000\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ical.Parse(strings.NewReader("BEGIN:VEVENT\n"+event+"END:VEVENT\n"), time.UTC)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := ical.Parse(strings.NewReader("BEGIN:VEVENT\nDTSTART:20261001T000000Z\n"), time.UTC); err == nil {
+		t.Error("expected an error for an unterminated event")
+	}
+}
+
+func TestTimeZones(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Budapest")
+	if err != nil {
+		t.Skip("time zone database unavailable:", err)
+	}
+
+	cal, err := ical.Parse(strings.NewReader("BEGIN:VEVENT\n"+
+		"DTSTART;TZID=America/New_York:20260701T090000\n"+
+		"DTEND;TZID=America/New_York:20260701T100000\n"+
+		"END:VEVENT\n"+
+		"BEGIN:VEVENT\n"+
+		"DTSTART:20260701T090000\n"+
+		"END:VEVENT\n"), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if start := cal.Events[0].Start; !start.Equal(date("2026-07-01T13:00:00Z")) || cal.Events[0].Duration != time.Hour {
+		t.Errorf("unexpected event with time zone: %v for %v", start, cal.Events[0].Duration)
+	}
+
+	if start := cal.Events[1].Start; !start.Equal(date("2026-07-01T07:00:00Z")) {
+		t.Errorf("unexpected floating event: %v", start)
+	}
+}
+
+func TestRules(t *testing.T) {
+	for name, test := range map[string]struct {
+		start    string
+		rule     string
+		expected []string
+	}{
+		"daily interval": {
+			start:    "20261001T120000Z",
+			rule:     "FREQ=DAILY;INTERVAL=3;UNTIL=20261010T120000Z",
+			expected: []string{"2026-10-01T12:00:00Z", "2026-10-04T12:00:00Z", "2026-10-07T12:00:00Z", "2026-10-10T12:00:00Z"},
+		},
+		"weekly": {
+			start:    "20261001T120000Z",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			expected: []string{"2026-10-01T12:00:00Z", "2026-10-15T12:00:00Z", "2026-10-29T12:00:00Z"},
+		},
+		"monthly skips short months": {
+			start:    "20260131T000000Z",
+			rule:     "FREQ=MONTHLY;COUNT=3",
+			expected: []string{"2026-01-31T00:00:00Z", "2026-03-31T00:00:00Z", "2026-05-31T00:00:00Z"},
+		},
+		"yearly": {
+			start:    "20261225T000000Z",
+			rule:     "FREQ=YEARLY",
+			expected: []string{"2026-12-25T00:00:00Z", "2027-12-25T00:00:00Z", "2028-12-25T00:00:00Z"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cal, err := ical.Parse(strings.NewReader("BEGIN:VEVENT\nDTSTART:"+test.start+"\nRRULE:"+test.rule+"\nEND:VEVENT\n"), time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			occurrences := cal.Events[0].Occurrences(date("2028-12-31T00:00:00Z"))
+			if len(occurrences) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, occurrences)
+			}
+
+			for i, expected := range test.expected {
+				if !occurrences[i].Equal(date(expected)) {
+					t.Errorf("expected %v, got %v", test.expected, occurrences)
+				}
+			}
+		})
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	cal, err := ical.Parse(strings.NewReader(holidays), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for at, expected := range map[string]string{
+		"2026-12-23T23:59:59Z": "",
+		"2026-12-24T00:00:00Z": "2027-01-02T00:00:00Z",
+		"2027-01-01T12:00:00Z": "2027-01-02T00:00:00Z",
+		"2027-01-02T00:00:00Z": "",
+		"2026-10-08T23:00:00Z": "2026-10-09T00:00:00Z",
+		"2026-10-06T23:00:00Z": "", // Excluded
+		"2026-10-20T23:00:00Z": "", // Past the count
+	} {
+		end, ok := cal.Blocked(date(at))
+		if ok != (expected != "") || (ok && !end.Equal(date(expected))) {
+			t.Errorf("at %s: expected %q, got %v, %v", at, expected, end, ok)
+		}
+	}
+
+	windows := cal.Windows(date("2026-10-05T00:00:00Z"), date("2026-12-25T00:00:00Z"))
+	if len(windows) != 3 {
+		t.Errorf("expected 3 windows, got %v", windows)
+	}
+}
+
+func TestTickerSkipsCalendar(t *testing.T) {
+	clock := jittertest.NewClock(date("2026-12-23T00:00:00Z"))
+
+	cal, err := ical.Parse(strings.NewReader(holidays), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticker := jitter.NewTicker(24*time.Hour, time.Hour, jitter.WithClock(clock), jitter.SkipDuring(cal))
+	defer ticker.Stop()
+
+	for _, at := range ticker.Upcoming(3) {
+		if _, ok := cal.Blocked(at); ok {
+			t.Errorf("event scheduled during a blackout: %v", at)
+		}
+	}
+
+	if first := ticker.Upcoming(2)[1]; first.Before(date("2027-01-02T00:00:00Z")) {
+		t.Errorf("expected the second event after the freeze, got %v", first)
+	}
+}
+
+func TestNestedComponents(t *testing.T) {
+	cal, err := ical.Parse(strings.NewReader("BEGIN:VCALENDAR\n"+
+		"BEGIN:VEVENT\n"+
+		"SUMMARY:December freeze\n"+
+		"DTSTART:20261201T000000Z\n"+
+		"BEGIN:VALARM\n"+
+		"ACTION:EMAIL\n"+
+		"SUMMARY:Reminder\n"+
+		"DURATION:PT15M\n"+
+		"TRIGGER:-P1D\n"+
+		"END:VALARM\n"+
+		"DTEND:20261215T000000Z\n"+
+		"END:VEVENT\n"+
+		"END:VCALENDAR\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cal.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(cal.Events))
+	}
+
+	if event := cal.Events[0]; event.Summary != "December freeze" || event.Duration != 14*24*time.Hour {
+		t.Errorf("expected the properties of the alarm to be ignored, got %q for %v", event.Summary, event.Duration)
+	}
+
+	if _, ok := cal.Blocked(date("2026-12-05T00:00:00Z")); !ok {
+		t.Error("expected the freeze to block")
+	}
+
+	if _, err := ical.Parse(strings.NewReader("BEGIN:VEVENT\nDTSTART:20261201T000000Z\nBEGIN:VALARM\nEND:VEVENT\n"), time.UTC); err == nil {
+		t.Error("expected an error for mismatched components")
+	}
+}
+
+func TestTickerEndlessCalendar(t *testing.T) {
+	// Daily all-day events touch each other, so the blackout never ends
+	cal, err := ical.Parse(strings.NewReader("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20261001\nRRULE:FREQ=DAILY\nEND:VEVENT\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := jittertest.NewClock(date("2026-10-16T00:00:00Z"))
+
+	done := make(chan *jitter.Ticker)
+	go func() {
+		done <- jitter.NewTicker(time.Hour, time.Minute, jitter.WithClock(clock), jitter.DeferDuring(cal))
+	}()
+
+	select {
+	case ticker := <-done:
+		defer ticker.Stop()
+
+		if next := ticker.Next(); !next.IsZero() {
+			t.Errorf("expected the ticker to stop, next event at %v", next)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("NewTicker didn't return")
+	}
+}
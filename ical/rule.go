@@ -0,0 +1,163 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the unit of recurrence of a Rule
+type Frequency string
+
+// Frequencies supported by rules
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Rule is a simple recurrence rule
+type Rule struct {
+	Frequency Frequency      // Unit of recurrence
+	Interval  int            // Number of units between occurrences
+	Count     int            // Max number of occurrences, zero for no limit
+	Until     time.Time      // Time of the last possible occurrence, zero for no limit
+	ByDay     []time.Weekday // Days of the week of weekly rules, the day of the start when empty
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// parseRule parses an RRULE value, parts that aren't supported are an error so they don't get ignored silently
+func parseRule(value string, loc *time.Location) (*Rule, error) {
+	rule := &Rule{
+		Interval: 1,
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed part: %q", part)
+		}
+
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Frequency = Frequency(strings.ToUpper(value))
+			switch rule.Frequency {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				return nil, fmt.Errorf("unsupported frequency: %q", value)
+			}
+		case "INTERVAL":
+			if rule.Interval, err = strconv.Atoi(value); err != nil || rule.Interval <= 0 {
+				return nil, fmt.Errorf("invalid interval: %q", value)
+			}
+		case "COUNT":
+			if rule.Count, err = strconv.Atoi(value); err != nil || rule.Count <= 0 {
+				return nil, fmt.Errorf("invalid count: %q", value)
+			}
+		case "UNTIL":
+			if rule.Until, _, err = parseTime(property{value: value}, loc); err != nil {
+				return nil, fmt.Errorf("invalid until: %w", err)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported day: %q", day)
+				}
+
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				return nil, fmt.Errorf("unsupported week start: %q", value)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported part: %q", name)
+		}
+	}
+
+	if rule.Frequency == "" {
+		return nil, fmt.Errorf("missing frequency")
+	}
+
+	if len(rule.ByDay) > 0 && rule.Frequency != Weekly {
+		return nil, fmt.Errorf("BYDAY is only supported for weekly rules")
+	}
+
+	return rule, nil
+}
+
+// each calls f with the starts of the occurrences in order, up to and including until or until f returns false
+// Occurrences that don't exist, such as the 31st of a shorter month, are skipped
+func (r *Rule) each(start time.Time, until time.Time, f func(start time.Time) bool) {
+	count := 0
+	emit := func(occurrence time.Time) bool {
+		if occurrence.After(until) || (!r.Until.IsZero() && occurrence.After(r.Until)) {
+			return false
+		}
+
+		count++
+		if r.Count > 0 && count > r.Count {
+			return false
+		}
+
+		return f(occurrence)
+	}
+
+	if r.Frequency == Weekly && len(r.ByDay) > 0 {
+		// Walk the weeks starting on Monday, at the time of day of the start
+		monday := start.AddDate(0, 0, -fromMonday(start.Weekday()))
+		days := sortedWeekdays(r.ByDay)
+		for week := 0; ; week += r.Interval {
+			for _, day := range days {
+				occurrence := monday.AddDate(0, 0, 7*week+fromMonday(day))
+				if occurrence.Before(start) {
+					continue
+				}
+
+				if !emit(occurrence) {
+					return
+				}
+			}
+		}
+	}
+
+	for i := 0; ; i += r.Interval {
+		var occurrence time.Time
+		switch r.Frequency {
+		case Daily:
+			occurrence = start.AddDate(0, 0, i)
+		case Weekly:
+			occurrence = start.AddDate(0, 0, 7*i)
+		case Monthly:
+			occurrence = start.AddDate(0, i, 0)
+		case Yearly:
+			occurrence = start.AddDate(i, 0, 0)
+		}
+
+		// AddDate normalizes missing days into the next month, those occurrences don't exist
+		if (r.Frequency == Monthly || r.Frequency == Yearly) && occurrence.Day() != start.Day() {
+			if occurrence.After(until) {
+				return
+			}
+
+			continue
+		}
+
+		if !emit(occurrence) {
+			return
+		}
+	}
+}
@@ -2,76 +2,429 @@ package jitter
 
 import (
 	"fmt"
-	"math/rand"
+	"runtime"
+	"sync"
 	"time"
 )
 
 // Ticker is a ticker that emits events on a channel at the given interval, with an added delay up to the defined max jitter
 // If the receiever doesn't keep up the events will be discarded
+// The ticker is driven by a timer that is re-armed after every event, so an idle ticker doesn't hold a goroutine
 type Ticker struct {
 	C <-chan time.Time // Channel which the events are delivered on
+	c chan<- time.Time // Sending side of C
 
+	maxDrift time.Duration // Max distance from the nominal grid when rate-preserving, zero when disabled
+	spin     time.Duration // Final stretch before an event spent busy-waiting, zero when disabled
+	source   *Source       // Source of randomness for generating jitter, nil for the runtime's generators
+	clock    Clock         // Clock the ticker runs on
+	recorder *Recorder     // Recorder the events are written to, nil when not recording
+	replay   *Timeline     // Timeline the events are replayed from, nil for a live ticker
+	blackout Blackout      // Periods events are kept out of, nil for none
+	postpone bool          // Whether events in a blackout are postponed rather than skipped
+
+	mu       sync.Mutex    // Protects everything below
 	interval time.Duration // Interval for the ticker to run at
 	jitter   time.Duration // Max jitter to add to the interval
+	deadline time.Time     // Time by which an event has to be delivered, zero when there's none or the event for it is planned
+	due      time.Time     // Deadline that no delivered event has met yet, applied again when the ticker is restarted
+	timer    Timer         // Timer firing the next event
+	gen      uint64        // Incremented on every Stop and Reset, so timers armed before are ignored
+	stopped  bool          // Whether the ticker is stopped
+
+	planned  []Event       // Events committed to, the timer is armed for the first one
+	origin   time.Time     // Time the ticker was last started
+	tail     time.Time     // Time of the last planned event
+	grid     time.Time     // Nominal time of the last planned event when rate-preserving
+	drift    time.Duration // Offset of tail from grid when rate-preserving
+	replayed int           // Number of events of the replayed timeline planned so far
+	blocked  bool          // Whether the blackout didn't end within the look-ahead, so no more events are planned
+
+	errors int           // Number of events measured
+	total  time.Duration // Sum of the lateness of all measured events
+	last   time.Duration // Lateness of the last event
+	max    time.Duration // Worst lateness seen
+}
 
-	stop   chan struct{} // Channel used for stopping the timer
-	random *rand.Rand    // Local random for generating jitter
+// TimingError summarises how late events were delivered compared to the time they were scheduled for
+type TimingError struct {
+	Events int           // Number of events measured
+	Last   time.Duration // Lateness of the last event
+	Mean   time.Duration // Mean lateness
+	Max    time.Duration // Worst lateness
 }
 
 // NewTicker returns a new ticker with the given interval and jitter
-func NewTicker(interval time.Duration, jitter time.Duration) *Ticker {
-	if interval <= 0 {
-		panic(fmt.Errorf("non-positive interval for NewTicker: %d", int(interval)))
-	}
+func NewTicker(interval time.Duration, jitter time.Duration, opts ...Option) *Ticker {
+	checkInterval("NewTicker", interval, jitter)
 
-	if jitter <= 0 {
-		panic(fmt.Errorf("non-positive jitter for NewTicker: %d", int(jitter)))
-	}
+	return newTicker(interval, jitter, nil, opts)
+}
+
+// NewReplayTicker returns a new ticker that fires at the same times relative to its start as the recorded ticker did, then stops
+// Reset restarts the replay from the beginning of the timeline, the interval and jitter passed to it are ignored
+func NewReplayTicker(timeline *Timeline, opts ...Option) *Ticker {
+	return newTicker(0, 0, timeline, opts)
+}
 
-	// Create a seeded random to use for the jitter
-	source := rand.NewSource(time.Now().UnixNano())
-	random := rand.New(source)
+func newTicker(interval time.Duration, jitter time.Duration, replay *Timeline, opts []Option) *Ticker {
+	o := newOptions(opts)
+	if replay == nil {
+		checkDrift("NewTicker", interval, o.maxDrift)
+	}
 
 	// Create a buffered channel for tick events
 	c := make(chan time.Time, 1)
 	ticker := &Ticker{
 		C: c,
+		c: c,
+
+		maxDrift: o.maxDrift,
+		spin:     o.spin,
+		source:   o.source,
+		clock:    o.clock,
+		recorder: o.recorder,
+		replay:   replay,
+		blackout: o.blackout,
+		postpone: o.postpone,
 
 		interval: interval,
 		jitter:   jitter,
+		deadline: o.deadline,
+		due:      o.deadline,
+	}
 
-		stop:   make(chan struct{}),
-		random: random,
+	// Busy-waiting on any other clock would never end, as time doesn't pass while waiting
+	if ticker.clock != RealClock {
+		ticker.spin = 0
 	}
 
-	// Run the ticker
-	// Ticker.C is a receive-only channel, so we need to pass it
-	go ticker.tick(c)
+	ticker.mu.Lock()
+	defer ticker.mu.Unlock()
+
+	ticker.start()
 
 	return ticker
 }
 
-func (t Ticker) tick(c chan<- time.Time) {
-loop:
-	for {
-		t.sleep() // Sleep for duration + jitter
+func checkInterval(name string, interval time.Duration, jitter time.Duration) {
+	if interval <= 0 {
+		panic(fmt.Errorf("non-positive interval for %s: %d", name, int(interval)))
+	}
+
+	if jitter <= 0 {
+		panic(fmt.Errorf("non-positive jitter for %s: %d", name, int(jitter)))
+	}
+}
+
+// checkDrift panics if a rate-preserving ticker could schedule an event before the previous one,
+// which takes a change in drift of at least the interval between them
+func checkDrift(name string, interval time.Duration, maxDrift time.Duration) {
+	if maxDrift > 0 && 2*maxDrift >= interval {
+		panic(fmt.Errorf("max drift of at least half the interval for %s: %d", name, int(maxDrift)))
+	}
+}
+
+// start schedules the first event counting from now, t.mu must be held
+func (t *Ticker) start() {
+	now := t.clock.Now()
+	t.stopped = false
+	t.planned = t.planned[:0]
+	t.origin = now
+	t.tail = now
+	t.grid = now
+	t.drift = 0
+	t.replayed = 0
+	t.blocked = false
+	t.deadline = t.due // Planned events are dropped, including the one for the deadline
+
+	if t.recorder != nil {
+		t.recorder.begin(now)
+	}
+
+	if !t.schedule() {
+		t.stopped = true
+		return
+	}
+
+	t.arm()
+}
 
-		select {
-		case <-t.stop: // Check for the stop signal and stop
-			break loop
-		case c <- time.Now(): // Send the time event to the ticker channel
-		default: // Fall-through so that sending to the channel doesn't block
+// arm starts a timer for the next event, t.mu must be held
+func (t *Ticker) arm() {
+	gen := t.gen
+	t.timer = t.clock.AfterFunc(t.planned[0].At.Sub(t.clock.Now())-t.spin, func() {
+		t.fire(gen)
+	})
+}
+
+// fire delivers the event the timer was armed for, unless the ticker was stopped or reset since
+func (t *Ticker) fire(gen uint64) {
+	t.mu.Lock()
+	if gen != t.gen {
+		t.mu.Unlock()
+		return
+	}
+
+	next := t.planned[0]
+	t.mu.Unlock()
+
+	now := t.wait(next.At) // Busy-wait for the final stretch in high-precision mode
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if gen != t.gen {
+		return
+	}
+
+	t.measure(now.Sub(next.At))
+	if !t.due.IsZero() && !next.At.After(t.due) {
+		t.due = time.Time{} // The deadline is met
+	}
+
+	if t.recorder != nil {
+		t.recorder.record(now, next.Jitter)
+	}
+
+	select {
+	case t.c <- now: // Send the time event to the ticker channel
+	default: // Fall-through so that sending to the channel doesn't block
+	}
+
+	t.planned = t.planned[1:]
+	if len(t.planned) == 0 && !t.schedule() {
+		t.stopped = true // The replayed timeline has ended
+		return
+	}
+
+	t.arm()
+}
+
+// wait busy-waits until next in high-precision mode and returns the current time
+func (t *Ticker) wait(next time.Time) time.Time {
+	now := t.clock.Now()
+	if t.spin == 0 {
+		return now
+	}
+
+	for now.Before(next) {
+		runtime.Gosched()
+		now = t.clock.Now()
+	}
+
+	return now
+}
+
+// measure records the lateness of an event, t.mu must be held
+func (t *Ticker) measure(late time.Duration) {
+	t.errors++
+	t.total += late
+	t.last = late
+	if late > t.max {
+		t.max = late
+	}
+}
+
+// TimingError returns how late the events of the ticker have been compared to their scheduled time
+func (t *Ticker) TimingError() TimingError {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := TimingError{
+		Events: t.errors,
+		Last:   t.last,
+		Max:    t.max,
+	}
+
+	if t.errors > 0 {
+		stats.Mean = t.total / time.Duration(t.errors)
+	}
+
+	return stats
+}
+
+// Next returns the time the next event is scheduled for, or the zero time if the ticker is stopped
+func (t *Ticker) Next() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return time.Time{}
+	}
+
+	return t.planned[0].At
+}
+
+// Upcoming returns the times of the next n events, or nil if the ticker is stopped
+// The jitter of these events is sampled ahead of time and the ticker is committed to fire at exactly these times, unless it's reset
+// A replay ticker returns fewer times when its timeline ends sooner
+func (t *Ticker) Upcoming(n int) []time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return nil
+	}
+
+	for len(t.planned) < n && t.schedule() {
+	}
+
+	upcoming := make([]time.Time, 0, n)
+	for _, event := range t.planned {
+		if len(upcoming) == n {
+			break
+		}
+
+		upcoming = append(upcoming, event.At)
+	}
+
+	return upcoming
+}
+
+// schedule plans the event following the last planned one and reports whether there is one, t.mu must be held
+// There is none once a replayed timeline ends, or when a blackout doesn't end within the look-ahead
+// Events are scheduled relative to the previous one rather than to the time it was delivered, so sleep overshoot doesn't accumulate
+func (t *Ticker) schedule() bool {
+	if t.replay != nil {
+		if t.replayed == len(t.replay.Events) {
+			return false
+		}
+
+		event := t.replay.Events[t.replayed]
+		t.replayed++
+		t.tail = t.origin.Add(event.At.Sub(t.replay.Origin))
+		t.planned = append(t.planned, Event{At: t.tail, Jitter: event.Jitter})
+
+		return true
+	}
+
+	if t.blocked {
+		return false
+	}
+
+	prev := t.tail
+	t.planEvent()
+	if !t.applyBlackout() {
+		if t.deadline.IsZero() {
+			t.blocked = true
+			return false
+		}
+
+		t.tail = t.deadline // The deadline takes precedence over the blackout
+	}
+
+	t.applyDeadline()
+	t.planned = append(t.planned, Event{At: t.tail, Jitter: t.tail.Sub(prev) - t.interval})
+
+	return true
+}
+
+// planEvent moves tail to the time of the following event, t.mu must be held
+func (t *Ticker) planEvent() {
+	if t.maxDrift == 0 {
+		base := t.tail.Add(t.interval)
+		jitter := t.jitter
+		if !t.deadline.IsZero() {
+			jitter = minDuration(jitter, t.deadline.Sub(base))
 		}
+
+		t.tail = base.Add(t.source.Duration(0, jitter))
+		return
 	}
+
+	t.grid = t.grid.Add(t.interval)
+	t.drift += t.source.Duration(0, t.jitter) - t.jitter/2
+	t.drift = clampDuration(t.drift, -t.maxDrift, t.maxDrift)
+
+	t.tail = t.grid.Add(t.drift)
 }
 
-func (t Ticker) sleep() {
-	jitter := int64(t.jitter)
-	delay := time.Duration(t.random.Int63n(jitter))
-	time.Sleep(t.interval + delay)
+// MaxBlackoutPeriods is the number of consecutive blackout periods an event is moved past before the blackout is taken to never end
+const MaxBlackoutPeriods = 1000
+
+// applyBlackout moves tail out of blackout periods, t.mu must be held
+// It reports false if tail is still blacked out after MaxBlackoutPeriods periods, such as when the periods never end
+func (t *Ticker) applyBlackout() bool {
+	if t.blackout == nil {
+		return true
+	}
+
+	// Keep going, as the new time may fall into another period
+	for i := 0; i < MaxBlackoutPeriods; i++ {
+		end, ok := t.blackout.Blocked(t.tail)
+		if !ok || !end.After(t.tail) {
+			return true
+		}
+
+		if t.postpone {
+			// Start a new grid from the deferred event, so the following ones aren't planned in the blackout again
+			t.tail = end.Add(t.source.Duration(0, t.jitter))
+			t.grid = t.tail
+			t.drift = 0
+			continue
+		}
+
+		// Plan the event again as if the previous one happened at the end
+		t.tail = end
+		t.grid = end
+		t.drift = 0
+		t.planEvent()
+	}
+
+	_, ok := t.blackout.Blocked(t.tail)
+	return !ok
 }
 
-// Stop will stop the ticker and return immediately
-func (t Ticker) Stop() {
-	close(t.stop)
+// applyDeadline moves tail back to the deadline if it would be due after it, t.mu must be held
+func (t *Ticker) applyDeadline() {
+	if t.deadline.IsZero() || t.tail.Before(t.deadline) {
+		return
+	}
+
+	t.tail = t.deadline
+	t.deadline = time.Time{}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// Stop will stop the ticker and return immediately, no events are sent after it returns
+// Stopping a stopped ticker has no effect
+func (t *Ticker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stop()
+}
+
+// stop stops the timer and invalidates callbacks that may already be running, t.mu must be held
+func (t *Ticker) stop() {
+	t.gen++
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// Reset stops the ticker and restarts it with the given interval and jitter, the next event is scheduled counting from now
+// A stopped ticker is started again
+func (t *Ticker) Reset(interval time.Duration, jitter time.Duration) {
+	checkInterval("Reset", interval, jitter)
+	if t.replay == nil {
+		checkDrift("Reset", interval, t.maxDrift)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stop()
+	t.interval = interval
+	t.jitter = jitter
+	t.start()
 }
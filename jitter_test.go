@@ -2,10 +2,12 @@ package jitter_test
 
 import (
 	"fmt"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
 )
 
 func TestNewTicker(t *testing.T) {
@@ -96,4 +98,341 @@ func TestStop(t *testing.T) {
 	if time.Since(start) > delay {
 		t.Error("Stop took too long")
 	}
+
+	ticker.Stop() // Stopping again has no effect
+}
+
+func TestStopDiscardsEvents(t *testing.T) {
+	ticker := jitter.NewTicker(time.Millisecond, time.Millisecond)
+	<-ticker.C
+	ticker.Stop()
+
+	// Drain an event that may have been sent before stopping
+	select {
+	case <-ticker.C:
+	default:
+	}
+
+	select {
+	case <-ticker.C:
+		t.Error("tick after Stop")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Run("panics on non-positive interval", func(t *testing.T) {
+		ticker := jitter.NewTicker(time.Second, time.Second)
+		defer ticker.Stop()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Reset did not panic on non-positive interval")
+			}
+		}()
+
+		ticker.Reset(0, time.Second)
+	})
+
+	t.Run("changes the interval", func(t *testing.T) {
+		ticker := jitter.NewTicker(time.Hour, time.Hour)
+		defer ticker.Stop()
+
+		ticker.Reset(10*time.Millisecond, time.Millisecond)
+
+		select {
+		case <-ticker.C:
+		case <-time.After(time.Second):
+			t.Error("no tick after Reset")
+		}
+	})
+
+	t.Run("restarts a stopped ticker", func(t *testing.T) {
+		ticker := jitter.NewTicker(time.Millisecond, time.Millisecond)
+		defer ticker.Stop()
+
+		ticker.Stop()
+		ticker.Reset(10*time.Millisecond, time.Millisecond)
+
+		select {
+		case <-ticker.C:
+		case <-time.After(time.Second):
+			t.Error("no tick after Reset")
+		}
+	})
+}
+
+func TestIdleTickerGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	tickers := make([]*jitter.Ticker, 1000)
+	for i := range tickers {
+		tickers[i] = jitter.NewTicker(time.Hour, time.Hour)
+	}
+
+	defer func() {
+		for _, ticker := range tickers {
+			ticker.Stop()
+		}
+	}()
+
+	if after := runtime.NumGoroutine(); after-before > 10 {
+		t.Errorf("%d goroutines for %d idle tickers", after-before, len(tickers))
+	}
+}
+
+func BenchmarkTickers100k(b *testing.B) {
+	const count = 100000
+	tickers := make([]*jitter.Ticker, count)
+
+	var before, after runtime.MemStats
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		for j := range tickers {
+			tickers[j] = jitter.NewTicker(time.Hour, time.Hour)
+		}
+
+		runtime.ReadMemStats(&after)
+
+		for _, ticker := range tickers {
+			ticker.Stop()
+		}
+	}
+
+	used := (after.HeapAlloc + after.StackInuse) - (before.HeapAlloc + before.StackInuse)
+	b.ReportMetric(float64(used)/count, "B/ticker")
+}
+
+func TestRatePreserving(t *testing.T) {
+	t.Run("panics on non-positive max drift", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("RatePreserving did not panic on non-positive max drift")
+			}
+		}()
+
+		jitter.RatePreserving(0)
+	})
+
+	t.Run("panics on a max drift of half the interval", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("NewTicker did not panic on a max drift of half the interval")
+			}
+		}()
+
+		jitter.NewTicker(time.Second, 10*time.Second, jitter.RatePreserving(500*time.Millisecond))
+	})
+
+	t.Run("keeps events in order with a large jitter", func(t *testing.T) {
+		start := time.Unix(0, 0)
+		clock := jittertest.NewClock(start)
+
+		ticker := jitter.NewTicker(time.Second, 10*time.Second, jitter.WithClock(clock), jitter.RatePreserving(400*time.Millisecond))
+		defer ticker.Stop()
+
+		prev := start
+		for i, at := range ticker.Upcoming(1000) {
+			if !at.After(prev) {
+				t.Fatalf("event %d at %v isn't after the previous one at %v", i, at.Sub(start), prev.Sub(start))
+			}
+
+			prev = at
+		}
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Reset did not panic on a max drift over half the interval")
+			}
+		}()
+
+		ticker.Reset(500*time.Millisecond, time.Second)
+	})
+
+	t.Run("stays within the max drift of the grid", func(t *testing.T) {
+		interval := 20 * time.Millisecond
+		maxDrift := 5 * time.Millisecond
+		slack := 10 * time.Millisecond // Allowance for scheduling latency
+
+		start := time.Now()
+		ticker := jitter.NewTicker(interval, 4*interval, jitter.RatePreserving(maxDrift))
+		defer ticker.Stop()
+
+		for i := 1; i <= 10; i++ {
+			tick := <-ticker.C
+			drift := tick.Sub(start) - time.Duration(i)*interval
+			if drift < -maxDrift || drift > maxDrift+slack {
+				t.Errorf("tick %d drifted %v from the grid", i, drift)
+			}
+		}
+	})
+}
+
+func TestHardDeadline(t *testing.T) {
+	t.Run("panics on negative margin", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("HardDeadline did not panic on negative margin")
+			}
+		}()
+
+		jitter.HardDeadline(time.Now(), -time.Second)
+	})
+
+	t.Run("delivers an event before the deadline", func(t *testing.T) {
+		interval := 50 * time.Millisecond
+		margin := 20 * time.Millisecond
+		slack := 10 * time.Millisecond // Allowance for scheduling latency
+
+		start := time.Now()
+		deadline := start.Add(100 * time.Millisecond)
+		ticker := jitter.NewTicker(interval, time.Hour, jitter.HardDeadline(deadline, margin))
+		defer ticker.Stop()
+
+		for i := 0; i < 2; i++ {
+			select {
+			case tick := <-ticker.C:
+				if tick.After(deadline.Add(-margin + slack)) {
+					t.Errorf("tick %d at %v is past the deadline", i, tick.Sub(start))
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("no tick %d before the deadline", i)
+			}
+		}
+	})
+
+	t.Run("survives a reset until it's met", func(t *testing.T) {
+		start := time.Unix(0, 0)
+		deadline := start.Add(30 * time.Minute)
+		clock := jittertest.NewClock(start)
+
+		ticker := jitter.NewTicker(time.Hour, time.Hour, jitter.WithClock(clock), jitter.HardDeadline(deadline, 0))
+		defer ticker.Stop()
+
+		clock.Advance(time.Minute)
+		ticker.Reset(time.Hour, time.Hour)
+		if next := ticker.Next(); !next.Equal(deadline) {
+			t.Errorf("next event at %v after a reset, expected the deadline %v", next.Sub(start), deadline.Sub(start))
+		}
+
+		// Planning ahead doesn't use up the deadline either
+		ticker.Upcoming(3)
+		ticker.Reset(time.Hour, time.Hour)
+		if next := ticker.Next(); !next.Equal(deadline) {
+			t.Errorf("next event at %v after planning ahead and a reset, expected the deadline %v", next.Sub(start), deadline.Sub(start))
+		}
+
+		// Once met, the deadline no longer applies
+		clock.Advance(29 * time.Minute)
+		select {
+		case <-ticker.C:
+		default:
+			t.Fatal("no event at the deadline")
+		}
+
+		ticker.Reset(time.Hour, time.Hour)
+		if next := ticker.Next(); next.Sub(deadline) < time.Hour {
+			t.Errorf("next event at %v after the deadline was met, expected at least an interval later", next.Sub(start))
+		}
+	})
+
+	t.Run("fires immediately if the deadline has passed", func(t *testing.T) {
+		start := time.Now()
+		ticker := jitter.NewTicker(time.Hour, time.Hour, jitter.HardDeadline(start, time.Minute))
+		defer ticker.Stop()
+
+		select {
+		case <-ticker.C:
+		case <-time.After(time.Second):
+			t.Fatal("no tick for a passed deadline")
+		}
+	})
+}
+
+func TestHighPrecision(t *testing.T) {
+	t.Run("panics on non-positive spin", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("HighPrecision did not panic on non-positive spin")
+			}
+		}()
+
+		jitter.HighPrecision(0)
+	})
+
+	t.Run("reports timing error", func(t *testing.T) {
+		ticker := jitter.NewTicker(500*time.Microsecond, time.Microsecond, jitter.HighPrecision(200*time.Microsecond))
+		defer ticker.Stop()
+
+		for i := 0; i < 10; i++ {
+			<-ticker.C
+		}
+
+		stats := ticker.TimingError()
+		if stats.Events < 10 {
+			t.Errorf("measured %d events, expected at least 10", stats.Events)
+		}
+
+		if stats.Mean < 0 || stats.Max < stats.Mean {
+			t.Errorf("inconsistent timing error: %+v", stats)
+		}
+	})
+}
+
+func benchmarkTimingError(b *testing.B, opts ...jitter.Option) {
+	ticker := jitter.NewTicker(500*time.Microsecond, time.Microsecond, opts...)
+	defer ticker.Stop()
+
+	for i := 0; i < b.N; i++ {
+		<-ticker.C
+	}
+
+	stats := ticker.TimingError()
+	b.ReportMetric(float64(stats.Mean.Nanoseconds()), "mean-err-ns")
+	b.ReportMetric(float64(stats.Max.Nanoseconds()), "max-err-ns")
+}
+
+// The spin has to cover the overshoot of time.Sleep on the machine, 200µs is enough on most Linux systems
+func BenchmarkTimingError(b *testing.B) {
+	b.Run("sleep", func(b *testing.B) {
+		benchmarkTimingError(b)
+	})
+
+	b.Run("precision", func(b *testing.B) {
+		benchmarkTimingError(b, jitter.HighPrecision(200*time.Microsecond))
+	})
+}
+
+func TestUpcoming(t *testing.T) {
+	slack := 10 * time.Millisecond // Allowance for scheduling latency
+
+	ticker := jitter.NewTicker(20*time.Millisecond, 20*time.Millisecond)
+	defer ticker.Stop()
+
+	upcoming := ticker.Upcoming(3)
+	if len(upcoming) != 3 {
+		t.Fatalf("got %d upcoming events, expected 3", len(upcoming))
+	}
+
+	if next := ticker.Next(); !next.Equal(upcoming[0]) {
+		t.Errorf("Next() = %v, expected %v", next, upcoming[0])
+	}
+
+	for i, planned := range upcoming {
+		tick := <-ticker.C
+		if tick.Before(planned) || tick.After(planned.Add(slack)) {
+			t.Errorf("tick %d at %v, planned for %v", i, tick, planned)
+		}
+	}
+
+	ticker.Stop()
+	if next := ticker.Next(); !next.IsZero() {
+		t.Errorf("Next() = %v after Stop, expected zero time", next)
+	}
+
+	if upcoming := ticker.Upcoming(3); upcoming != nil {
+		t.Errorf("Upcoming(3) = %v after Stop, expected nil", upcoming)
+	}
 }
@@ -0,0 +1,143 @@
+// Package jittertest provides utilities for testing code that uses the jitter package
+package jittertest
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Clock is a fake jitter.Clock whose time only moves when it's advanced
+// Functions passed to AfterFunc are called synchronously by Advance, in the order they are due
+type Clock struct {
+	mu     sync.Mutex // Protects everything below
+	now    time.Time  // Current fake time
+	timers timerHeap  // Pending timers ordered by due time
+	seq    uint64     // Sequence number of the last timer, used to order timers due at the same time
+}
+
+// NewClock returns a new fake clock set to the given time
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now: now,
+	}
+}
+
+// Now returns the current fake time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// AfterFunc schedules f to be called by Advance once the clock has moved d forward
+// Timers that are already due are called on the next Advance, even when it's by zero
+func (c *Clock) AfterFunc(d time.Duration, f func()) jitter.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	t := &timer{
+		clock: c,
+		at:    c.now.Add(d),
+		seq:   c.seq,
+		f:     f,
+	}
+
+	heap.Push(&c.timers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by d, calling the functions of the timers that become due on the way
+// The clock is set to the due time of each timer before its function is called
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+
+	for len(c.timers) > 0 && !c.timers[0].at.After(end) {
+		t := heap.Pop(&c.timers).(*timer)
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+
+		// Call without holding the lock, so the function can use the clock
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+
+	c.now = end
+	c.mu.Unlock()
+}
+
+// Pending returns the number of timers that haven't fired or been stopped yet
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// timer is a pending call on a fake clock
+type timer struct {
+	clock *Clock    // Clock the timer belongs to
+	at    time.Time // Time the timer is due at
+	seq   uint64    // Sequence number for ordering timers due at the same time
+	f     func()    // Function to call
+	index int       // Index in the heap, -1 once removed
+}
+
+// Stop removes the timer and reports whether it was still pending
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	if t.index < 0 {
+		return false
+	}
+
+	heap.Remove(&t.clock.timers, t.index)
+
+	return true
+}
+
+// timerHeap is a min-heap of timers ordered by due time
+type timerHeap []*timer
+
+func (h timerHeap) Len() int {
+	return len(h)
+}
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+
+	return h[i].at.Before(h[j].at)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x interface{}) {
+	t := x.(*timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1
+	*h = old[:len(old)-1]
+
+	return t
+}
@@ -0,0 +1,72 @@
+package jittertest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+var _ jitter.Clock = (*jittertest.Clock)(nil)
+
+func TestClockAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := jittertest.NewClock(start)
+
+	var fired []time.Duration
+	record := func() {
+		fired = append(fired, clock.Now().Sub(start))
+	}
+
+	clock.AfterFunc(2*time.Second, record)
+	clock.AfterFunc(time.Second, record)
+	clock.AfterFunc(time.Second, func() {
+		record()
+		clock.AfterFunc(time.Second, record) // Due within the same Advance
+	})
+	clock.AfterFunc(time.Hour, record)
+
+	clock.Advance(3 * time.Second)
+
+	expected := []time.Duration{time.Second, time.Second, 2 * time.Second, 2 * time.Second}
+	if len(fired) != len(expected) {
+		t.Fatalf("fired at %v, expected %v", fired, expected)
+	}
+
+	for i := range expected {
+		if fired[i] != expected[i] {
+			t.Fatalf("fired at %v, expected %v", fired, expected)
+		}
+	}
+
+	if now := clock.Now().Sub(start); now != 3*time.Second {
+		t.Errorf("clock at %v after advancing, expected 3s", now)
+	}
+
+	if pending := clock.Pending(); pending != 1 {
+		t.Errorf("%d pending timers, expected 1", pending)
+	}
+}
+
+func TestClockStop(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+
+	fired := false
+	timer := clock.AfterFunc(time.Second, func() {
+		fired = true
+	})
+
+	if !timer.Stop() {
+		t.Error("Stop() = false for a pending timer")
+	}
+
+	if timer.Stop() {
+		t.Error("Stop() = true for a stopped timer")
+	}
+
+	clock.Advance(time.Minute)
+	if fired {
+		t.Error("stopped timer fired")
+	}
+}
@@ -0,0 +1,138 @@
+package jittertest
+
+import (
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// SeedEnv is the environment variable that overrides the seed of the perturbation, to replay a failing run
+const SeedEnv = "JITTER_SEED"
+
+// PerturbConfig configures the perturbation of the schedule
+type PerturbConfig struct {
+	Seed             uint64        // Seed of the perturbation, taken from SeedEnv or chosen randomly when zero
+	MaxSleep         time.Duration // Max duration of a sleep, 1ms when zero
+	SleepProbability float64       // Probability of a point sleeping, 0.1 when zero
+	YieldProbability float64       // Probability of a point yielding the processor if it doesn't sleep, 0.5 when zero
+}
+
+// PerturbStats counts what the perturbation points did since perturbation was enabled
+type PerturbStats struct {
+	Points int64 // Number of points reached
+	Sleeps int64 // Number of points that slept
+	Yields int64 // Number of points that yielded the processor
+}
+
+// perturber is the state of an enabled perturbation
+type perturber struct {
+	cfg    PerturbConfig
+	source *jitter.Source
+
+	points atomic.Int64
+	sleeps atomic.Int64
+	yields atomic.Int64
+}
+
+var (
+	perturbMu sync.Mutex                // Serializes enabling and disabling
+	perturbed atomic.Pointer[perturber] // Enabled perturbation, nil when disabled
+)
+
+// Perturb is a point where code under test lets the schedule be perturbed, to shake out ordering bugs
+// While perturbation is enabled by EnablePerturbation, it randomly sleeps for a short time or yields the processor,
+// otherwise it does nothing and costs a single atomic load
+func Perturb() {
+	p := perturbed.Load()
+	if p == nil {
+		return
+	}
+
+	p.points.Add(1)
+
+	r := p.source.Float64()
+	switch {
+	case r < p.cfg.SleepProbability:
+		p.sleeps.Add(1)
+		time.Sleep(p.source.Duration(0, p.cfg.MaxSleep))
+	case r < p.cfg.SleepProbability+(1-p.cfg.SleepProbability)*p.cfg.YieldProbability:
+		p.yields.Add(1)
+		runtime.Gosched()
+	}
+}
+
+// EnablePerturbation enables the perturbation points until the end of the test and returns the seed, which is also logged
+// The points draw from a single seeded source, so running again with the same seed set in SeedEnv repeats the same sequence of
+// perturbations, although goroutines may still reach the points in a different order
+// Only one test can enable perturbation at a time, so tests using it can't run in parallel
+func EnablePerturbation(t testing.TB, cfg PerturbConfig) uint64 {
+	t.Helper()
+
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.Uint64()
+		if env := os.Getenv(SeedEnv); env != "" {
+			seed, err := strconv.ParseUint(env, 10, 64)
+			if err != nil {
+				t.Fatalf("invalid %s: %q", SeedEnv, env)
+			}
+
+			cfg.Seed = seed
+		}
+	}
+
+	if cfg.MaxSleep <= 0 {
+		cfg.MaxSleep = time.Millisecond
+	}
+
+	if cfg.SleepProbability <= 0 {
+		cfg.SleepProbability = 0.1
+	}
+
+	if cfg.YieldProbability <= 0 {
+		cfg.YieldProbability = 0.5
+	}
+
+	perturbMu.Lock()
+	defer perturbMu.Unlock()
+
+	if perturbed.Load() != nil {
+		t.Fatalf("perturbation already enabled by another test")
+	}
+
+	perturbed.Store(&perturber{
+		cfg:    cfg,
+		source: jitter.NewSource(cfg.Seed),
+	})
+
+	t.Cleanup(func() {
+		perturbMu.Lock()
+		defer perturbMu.Unlock()
+
+		perturbed.Store(nil)
+	})
+
+	t.Logf("perturbing the schedule with seed %d, rerun with %s=%d to replay", cfg.Seed, SeedEnv, cfg.Seed)
+
+	return cfg.Seed
+}
+
+// PerturbationStats returns what the perturbation points did since perturbation was enabled, or zero stats when it's disabled
+func PerturbationStats() PerturbStats {
+	p := perturbed.Load()
+	if p == nil {
+		return PerturbStats{}
+	}
+
+	return PerturbStats{
+		Points: p.points.Load(),
+		Sleeps: p.sleeps.Load(),
+		Yields: p.yields.Load(),
+	}
+}
@@ -0,0 +1,87 @@
+package jittertest_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/jittertest"
+)
+
+func TestPerturbDisabled(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		jittertest.Perturb()
+	}
+
+	if stats := jittertest.PerturbationStats(); stats != (jittertest.PerturbStats{}) {
+		t.Errorf("PerturbationStats() = %+v while disabled", stats)
+	}
+}
+
+func TestPerturbReplay(t *testing.T) {
+	cfg := jittertest.PerturbConfig{
+		Seed:     42,
+		MaxSleep: time.Microsecond,
+	}
+
+	var runs []jittertest.PerturbStats
+	for i := 0; i < 2; i++ {
+		t.Run("run", func(t *testing.T) {
+			if seed := jittertest.EnablePerturbation(t, cfg); seed != 42 {
+				t.Errorf("seed %d, expected 42", seed)
+			}
+
+			for j := 0; j < 1000; j++ {
+				jittertest.Perturb()
+			}
+
+			runs = append(runs, jittertest.PerturbationStats())
+		})
+	}
+
+	if runs[0] != runs[1] {
+		t.Errorf("runs with the same seed differ: %+v != %+v", runs[0], runs[1])
+	}
+
+	if runs[0].Points != 1000 || runs[0].Sleeps == 0 || runs[0].Yields == 0 {
+		t.Errorf("unexpected stats: %+v", runs[0])
+	}
+}
+
+func TestPerturbSeedEnv(t *testing.T) {
+	t.Setenv(jittertest.SeedEnv, "7")
+
+	if seed := jittertest.EnablePerturbation(t, jittertest.PerturbConfig{}); seed != 7 {
+		t.Errorf("seed %d, expected 7 from %s", seed, jittertest.SeedEnv)
+	}
+}
+
+// Perturbation points in concurrent code are safe under the race detector
+func TestPerturbConcurrent(t *testing.T) {
+	jittertest.EnablePerturbation(t, jittertest.PerturbConfig{MaxSleep: time.Microsecond})
+
+	var mu sync.Mutex
+	counter := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				jittertest.Perturb()
+				mu.Lock()
+				jittertest.Perturb()
+				counter++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if counter != 800 {
+		t.Errorf("counter %d, expected 800", counter)
+	}
+}
@@ -0,0 +1,128 @@
+package jitter
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures optional behaviour of a Ticker, or of the other schedulers in the package
+// Schedulers ignore the options that don't apply to them
+type Option func(*options)
+
+// options holds the settings configured by Options
+type options struct {
+	maxDrift time.Duration // Max distance from the nominal grid when rate-preserving, zero when disabled
+	deadline time.Time     // Time by which an event has to be delivered, zero when there's none
+	spin     time.Duration // Final stretch before an event spent busy-waiting, zero when disabled
+	source   *Source       // Source of randomness, nil for the runtime's generators
+	clock    Clock         // Clock to run on
+	recorder *Recorder     // Recorder the events are written to, nil when not recording
+	backoff  *Backoff      // Backoff after failures, nil for the default of the scheduler
+	blackout Blackout      // Periods events are kept out of, nil for none
+	postpone bool          // Whether events in a blackout are postponed rather than skipped
+}
+
+// newOptions applies opts to the default settings
+func newOptions(opts []Option) options {
+	o := options{
+		clock: RealClock,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// RatePreserving compensates for the jitter so that the long-run average rate is exactly one event per interval
+// Events are scheduled on the nominal interval grid plus a drift, which moves by a random step in [-jitter/2, jitter/2) on every event
+// and is clamped to [-maxDrift, maxDrift], so the ticker never gets further than maxDrift away from the grid
+// The max drift has to be less than half the interval so events stay in order, NewTicker and Reset panic otherwise
+func RatePreserving(maxDrift time.Duration) Option {
+	if maxDrift <= 0 {
+		panic(fmt.Errorf("non-positive max drift for RatePreserving: %d", int(maxDrift)))
+	}
+
+	return func(o *options) {
+		o.maxDrift = maxDrift
+	}
+}
+
+// HardDeadline guarantees that an event is delivered no later than margin before the deadline
+// While the deadline is ahead, jitter is only sampled within the room left before it, and if the next event would be due after it,
+// the event is moved to exactly deadline-margin instead. Once an event before it is delivered the deadline no longer applies,
+// until then it also applies to the events scheduled by Reset
+func HardDeadline(deadline time.Time, margin time.Duration) Option {
+	if margin < 0 {
+		panic(fmt.Errorf("negative margin for HardDeadline: %d", int(margin)))
+	}
+
+	return func(o *options) {
+		o.deadline = deadline.Add(-margin)
+	}
+}
+
+// SkipDuring drops the events that would happen during the blackout, the next event is scheduled an interval plus jitter after it ends
+// A rate-preserving ticker starts a new grid at the end of the blackout. A hard deadline takes precedence over the blackout
+// If an event is still blacked out after being moved past MaxBlackoutPeriods periods, the blackout is taken to never end and the ticker stops,
+// which Next reports as the zero time. The windows of Windows that overlap or touch count as a single period
+func SkipDuring(blackout Blackout) Option {
+	return func(o *options) {
+		o.blackout = blackout
+		o.postpone = false
+	}
+}
+
+// DeferDuring postpones the events that would happen during the blackout to its end plus a random jitter in [0, jitter),
+// so tickers deferred by the same blackout don't all fire the moment it ends. A rate-preserving ticker starts a new grid from the deferred event
+// A hard deadline takes precedence over the blackout
+// If an event is still blacked out after being moved past MaxBlackoutPeriods periods, the blackout is taken to never end and the ticker stops,
+// which Next reports as the zero time. The windows of Windows that overlap or touch count as a single period
+func DeferDuring(blackout Blackout) Option {
+	return func(o *options) {
+		o.blackout = blackout
+		o.postpone = true
+	}
+}
+
+// HighPrecision makes the ticker sleep until spin before each event, then busy-wait for the final stretch, yielding the processor while doing so
+// This trades CPU time for accuracy when the overshoot of time.Sleep is significant compared to the interval, e.g. sub-millisecond intervals
+func HighPrecision(spin time.Duration) Option {
+	if spin <= 0 {
+		panic(fmt.Errorf("non-positive spin for HighPrecision: %d", int(spin)))
+	}
+
+	return func(o *options) {
+		o.spin = spin
+	}
+}
+
+// WithSource draws the jitter from the given source, e.g. a seeded one for reproducible schedules
+func WithSource(source *Source) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+// WithClock runs on the given clock instead of RealClock
+// High-precision mode only applies to RealClock
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithRecorder writes the time and jitter of every event the ticker fires to the given recorder
+func WithRecorder(recorder *Recorder) Option {
+	return func(o *options) {
+		o.recorder = recorder
+	}
+}
+
+// WithBackoff waits according to the given backoff after failures, for the schedulers that retry failed operations
+func WithBackoff(backoff Backoff) Option {
+	return func(o *options) {
+		o.backoff = &backoff
+	}
+}
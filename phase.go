@@ -0,0 +1,195 @@
+package jitter
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	phaseGain  = 0.5  // Fraction of the phase error corrected per observation
+	periodGain = 0.25 // Fraction of the phase error per elapsed period applied to the period estimate
+)
+
+// PhaseTicker follows an external schedule, emitting events a jittered offset after the events it expects upstream
+// Like a phase-locked loop, it predicts the next upstream event from its estimated period and phase, and corrects both
+// by a fraction of the error whenever an upstream event is observed. It doesn't emit events before the first observation
+// If the receiever doesn't keep up the events will be discarded
+type PhaseTicker struct {
+	C <-chan time.Time // Channel which the events are delivered on
+	c chan<- time.Time // Sending side of C
+
+	offset  time.Duration // Delay of the events after the upstream ones
+	jitter  time.Duration // Max jitter added to the offset
+	nominal time.Duration // Initial period estimate, which bounds the estimate to within a factor of two
+	clock   Clock         // Clock the ticker runs on
+	source  *Source       // Source of randomness for generating jitter
+
+	mu       sync.Mutex    // Protects everything below
+	period   time.Duration // Estimated period of the upstream events
+	phase    time.Time     // Estimated time of the last upstream event, zero before the first observation
+	armedFor time.Time     // Upstream event the timer is armed for
+	firedFor time.Time     // Upstream event the last event was emitted for
+	timer    Timer         // Timer firing the next event
+	gen      uint64        // Incremented whenever the timer is re-armed or stopped, so timers armed before are ignored
+	stopped  bool          // Whether the ticker is stopped
+}
+
+// NewPhaseTicker returns a new phase-locked ticker with an initial estimate of the upstream period,
+// emitting events offset plus a random jitter in [0, jitter) after every expected upstream event
+func NewPhaseTicker(period time.Duration, offset time.Duration, jitter time.Duration, opts ...Option) *PhaseTicker {
+	checkInterval("NewPhaseTicker", period, jitter)
+
+	if offset < 0 {
+		panic(fmt.Errorf("negative offset for NewPhaseTicker: %d", int(offset)))
+	}
+
+	o := newOptions(opts)
+
+	// Create a buffered channel for tick events
+	c := make(chan time.Time, 1)
+
+	return &PhaseTicker{
+		C: c,
+		c: c,
+
+		offset:  offset,
+		jitter:  jitter,
+		nominal: period,
+		clock:   o.clock,
+		source:  o.source,
+
+		period: period,
+	}
+}
+
+// Observe feeds the time of an upstream event to the ticker, which corrects its estimates and reschedules its next event
+// Observations earlier than half a period before the last one are ignored
+func (p *PhaseTicker) Observe(at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return
+	}
+
+	if p.phase.IsZero() {
+		p.phase = at
+		p.arm()
+		return
+	}
+
+	// Match the observation to the closest expected upstream event, which may be several periods ahead if some were missed
+	periods := math.Round(float64(at.Sub(p.phase)) / float64(p.period))
+	if periods < 0 {
+		return
+	}
+
+	expected := p.phase.Add(time.Duration(periods * float64(p.period)))
+	err := at.Sub(expected)
+
+	p.phase = expected.Add(time.Duration(phaseGain * float64(err)))
+	if periods > 0 {
+		p.period += time.Duration(periodGain * float64(err) / periods)
+		p.period = clampDuration(p.period, p.nominal/2, p.nominal*2)
+	}
+
+	p.arm()
+}
+
+// Period returns the estimated period of the upstream events
+func (p *PhaseTicker) Period() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.period
+}
+
+// Expected returns the time of the next upstream event expected after now, or the zero time before the first observation
+func (p *PhaseTicker) Expected() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.phase.IsZero() {
+		return time.Time{}
+	}
+
+	now := p.clock.Now()
+	expected := p.phase
+	for !expected.After(now) {
+		expected = expected.Add(p.period)
+	}
+
+	return expected
+}
+
+// arm starts a timer for the event following the next upstream event that hasn't had one yet, p.mu must be held
+func (p *PhaseTicker) arm() {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+
+	// Skip the upstream events already emitted for, allowing for the phase moving by up to half a period
+	upstream := p.phase
+	for !p.firedFor.IsZero() && !upstream.After(p.firedFor.Add(p.period/2)) {
+		upstream = upstream.Add(p.period)
+	}
+
+	// Only catch up on the last missed upstream event
+	now := p.clock.Now()
+	for !upstream.Add(p.period + p.offset).After(now) {
+		upstream = upstream.Add(p.period)
+	}
+
+	p.gen++
+	p.armedFor = upstream
+
+	gen := p.gen
+	at := upstream.Add(p.source.Duration(p.offset, p.jitter))
+	p.timer = p.clock.AfterFunc(at.Sub(now), func() {
+		p.fire(gen)
+	})
+}
+
+// fire emits the event the timer was armed for, unless it was re-armed or stopped since
+func (p *PhaseTicker) fire(gen uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if gen != p.gen || p.stopped {
+		return
+	}
+
+	select {
+	case p.c <- p.clock.Now(): // Send the time event to the ticker channel
+	default: // Fall-through so that sending to the channel doesn't block
+	}
+
+	p.firedFor = p.armedFor
+	p.arm()
+}
+
+// Stop will stop the ticker and return immediately, no events are sent after it returns
+func (p *PhaseTicker) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopped = true
+	p.gen++
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+}
+
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+
+	if d > max {
+		return max
+	}
+
+	return d
+}
@@ -0,0 +1,86 @@
+package jitter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+func TestNewPhaseTicker(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("NewPhaseTicker did not panic on negative offset")
+		}
+	}()
+
+	jitter.NewPhaseTicker(time.Minute, -time.Second, time.Second)
+}
+
+func TestPhaseTicker(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := jittertest.NewClock(start)
+
+	offset := 5 * time.Second
+	jitterMax := 2 * time.Second
+	period := 61 * time.Second // The initial estimate is a second off
+
+	ticker := jitter.NewPhaseTicker(time.Minute, offset, jitterMax, jitter.WithClock(clock), jitter.WithSource(jitter.NewSource(1)))
+	defer ticker.Stop()
+
+	step := 100 * time.Millisecond
+	tolerance := 500 * time.Millisecond
+	events := 0
+
+	for i := 0; i < 30; i++ {
+		upstream := start.Add(10*time.Second + time.Duration(i)*period)
+
+		// Advance to the upstream event, checking the events emitted for the previous one on the way
+		for clock.Now().Before(upstream) {
+			clock.Advance(step)
+
+			select {
+			case tick := <-ticker.C:
+				events++
+				delay := tick.Sub(upstream.Add(-period))
+				if i > 10 && (delay < offset-tolerance || delay > offset+jitterMax+tolerance) {
+					t.Errorf("event %v after upstream event %d, expected within [%v, %v)", delay, i-1, offset, offset+jitterMax)
+				}
+			default:
+			}
+		}
+
+		ticker.Observe(upstream)
+	}
+
+	if events != 29 {
+		t.Errorf("%d events for 29 upstream events", events)
+	}
+
+	if estimate := ticker.Period(); estimate < period-tolerance || estimate > period+tolerance {
+		t.Errorf("Period() = %v, expected %v", estimate, period)
+	}
+
+	expected := ticker.Expected()
+	if upstream := start.Add(10*time.Second + 30*period); expected.Sub(upstream) > tolerance || upstream.Sub(expected) > tolerance {
+		t.Errorf("Expected() = %v, expected %v", expected.Sub(start), upstream.Sub(start))
+	}
+}
+
+func TestPhaseTickerWaitsForObservation(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+	ticker := jitter.NewPhaseTicker(time.Second, 0, time.Millisecond, jitter.WithClock(clock))
+	defer ticker.Stop()
+
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C:
+		t.Error("event before the first observation")
+	default:
+	}
+
+	if expected := ticker.Expected(); !expected.IsZero() {
+		t.Errorf("Expected() = %v before the first observation, expected zero time", expected)
+	}
+}
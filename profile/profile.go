@@ -0,0 +1,221 @@
+// Package profile captures runtime profiles at jittered times and keeps them in a directory
+// Jittering the captures keeps the profiles of a fleet from lining up, and from correlating with periodic work in the process
+package profile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Kind is a kind of profile
+type Kind string
+
+// Kinds of profiles that can be captured
+const (
+	CPU       Kind = "cpu"
+	Heap      Kind = "heap"
+	Goroutine Kind = "goroutine"
+	Mutex     Kind = "mutex"
+)
+
+// timeFormat is the format of the timestamps in file names, which sorts chronologically
+const timeFormat = "20060102T150405.000000000Z"
+
+// Config configures a Capturer
+type Config struct {
+	Dir      string        // Directory the profiles are written to, created if missing
+	Interval time.Duration // Interval of the captures
+	Jitter   time.Duration // Max jitter added to the interval
+	Kinds    []Kind        // Kinds of profiles to capture, all of them when empty
+
+	CPUDuration   time.Duration // Duration of CPU profiles, 10 seconds when zero
+	MutexFraction int           // Sampling rate of mutex contention events passed to runtime.SetMutexProfileFraction, left unchanged when zero
+
+	MaxFiles int   // Max number of profiles kept, the oldest ones are removed first, zero for no limit
+	MaxBytes int64 // Max total size of the profiles kept, zero for no limit
+
+	OnError func(error) // Called with the errors of captures when running, nil to ignore them
+}
+
+// Capturer captures profiles into a directory
+type Capturer struct {
+	cfg Config
+}
+
+// New returns a new capturer, creating its directory
+func New(cfg Config) (*Capturer, error) {
+	if len(cfg.Kinds) == 0 {
+		cfg.Kinds = []Kind{CPU, Heap, Goroutine, Mutex}
+	}
+
+	for _, kind := range cfg.Kinds {
+		switch kind {
+		case CPU, Heap, Goroutine, Mutex:
+		default:
+			return nil, fmt.Errorf("unknown profile kind: %q", kind)
+		}
+	}
+
+	if cfg.CPUDuration <= 0 {
+		cfg.CPUDuration = 10 * time.Second
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	if cfg.MutexFraction > 0 {
+		runtime.SetMutexProfileFraction(cfg.MutexFraction)
+	}
+
+	return &Capturer{
+		cfg: cfg,
+	}, nil
+}
+
+// Run captures profiles on a jittered ticker until the context is cancelled, the options are passed to the ticker
+// Captures don't overlap, ticks that arrive while capturing are discarded, and a capture interrupted by the cancellation isn't reported as an error
+func (c *Capturer) Run(ctx context.Context, opts ...jitter.Option) {
+	ticker := jitter.NewTicker(c.cfg.Interval, c.cfg.Jitter, opts...)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := c.Capture(ctx)
+			if err != nil && ctx.Err() == nil && c.cfg.OnError != nil {
+				c.cfg.OnError(err)
+			}
+		}
+	}
+}
+
+// Capture captures every configured kind of profile once, then removes the oldest profiles beyond the limits
+func (c *Capturer) Capture(ctx context.Context) error {
+	for _, kind := range c.cfg.Kinds {
+		var buf bytes.Buffer
+		if err := c.profile(ctx, kind, &buf); err != nil {
+			return fmt.Errorf("failed to capture %s profile: %w", kind, err)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		name := fmt.Sprintf("%s-%s.pprof", kind, time.Now().UTC().Format(timeFormat))
+		if err := os.WriteFile(filepath.Join(c.cfg.Dir, name), buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s profile: %w", kind, err)
+		}
+	}
+
+	return c.rotate()
+}
+
+// profile writes a profile of the given kind
+func (c *Capturer) profile(ctx context.Context, kind Kind, buf *bytes.Buffer) error {
+	if kind != CPU {
+		return pprof.Lookup(string(kind)).WriteTo(buf, 0)
+	}
+
+	if err := pprof.StartCPUProfile(buf); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(c.cfg.CPUDuration):
+	}
+
+	pprof.StopCPUProfile()
+
+	return nil
+}
+
+// profileFile is a profile in the directory
+type profileFile struct {
+	name string
+	at   string // Timestamp part of the name
+	size int64
+}
+
+// rotate removes the oldest profiles until the rest are within the limits
+func (c *Capturer) rotate() error {
+	if c.cfg.MaxFiles <= 0 && c.cfg.MaxBytes <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(c.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	var files []profileFile
+	var total int64
+	for _, entry := range entries {
+		f, ok := parseName(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue // Leave files that weren't written by a capturer alone
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since listing
+		}
+
+		f.size = info.Size()
+		total += f.size
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].at < files[j].at
+	})
+
+	for len(files) > 0 && ((c.cfg.MaxFiles > 0 && len(files) > c.cfg.MaxFiles) || (c.cfg.MaxBytes > 0 && total > c.cfg.MaxBytes)) {
+		if err := os.Remove(filepath.Join(c.cfg.Dir, files[0].name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove profile: %w", err)
+		}
+
+		total -= files[0].size
+		files = files[1:]
+	}
+
+	return nil
+}
+
+// parseName parses the name of a profile written by a capturer
+func parseName(name string) (profileFile, bool) {
+	base, ok := strings.CutSuffix(name, ".pprof")
+	if !ok {
+		return profileFile{}, false
+	}
+
+	kind, at, ok := strings.Cut(base, "-")
+	if !ok {
+		return profileFile{}, false
+	}
+
+	switch Kind(kind) {
+	case CPU, Heap, Goroutine, Mutex:
+	default:
+		return profileFile{}, false
+	}
+
+	if _, err := time.Parse(timeFormat, at); err != nil {
+		return profileFile{}, false
+	}
+
+	return profileFile{name: name, at: at}, true
+}
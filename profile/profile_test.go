@@ -0,0 +1,147 @@
+package profile_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/profile"
+)
+
+func names(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to list %s: %v", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestCapture(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	capturer, err := profile.New(profile.Config{
+		Dir:         dir,
+		CPUDuration: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create capturer: %v", err)
+	}
+
+	if err := capturer.Capture(context.Background()); err != nil {
+		t.Fatalf("failed to capture: %v", err)
+	}
+
+	files := names(t, dir)
+	for _, kind := range []string{"cpu", "heap", "goroutine", "mutex"} {
+		found := false
+		for _, name := range files {
+			if strings.HasPrefix(name, kind+"-") {
+				found = true
+
+				info, err := os.Stat(filepath.Join(dir, name))
+				if err != nil || info.Size() == 0 {
+					t.Errorf("empty %s profile: %v", kind, err)
+				}
+			}
+		}
+
+		if !found {
+			t.Errorf("no %s profile in %v", kind, files)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("keep"), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", other, err)
+	}
+
+	capturer, err := profile.New(profile.Config{
+		Dir:      dir,
+		Kinds:    []profile.Kind{profile.Heap, profile.Goroutine},
+		MaxFiles: 3,
+	})
+	if err != nil {
+		t.Fatalf("failed to create capturer: %v", err)
+	}
+
+	var last []string
+	for i := 0; i < 3; i++ {
+		if err := capturer.Capture(context.Background()); err != nil {
+			t.Fatalf("failed to capture: %v", err)
+		}
+
+		last = names(t, dir)
+	}
+
+	if len(last) != 4 {
+		t.Fatalf("%d files after rotating, expected 3 profiles and the other file: %v", len(last), last)
+	}
+
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("rotating removed an unrelated file: %v", err)
+	}
+}
+
+func TestRotateBytes(t *testing.T) {
+	dir := t.TempDir()
+	capturer, err := profile.New(profile.Config{
+		Dir:      dir,
+		Kinds:    []profile.Kind{profile.Goroutine},
+		MaxBytes: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create capturer: %v", err)
+	}
+
+	if err := capturer.Capture(context.Background()); err != nil {
+		t.Fatalf("failed to capture: %v", err)
+	}
+
+	if files := names(t, dir); len(files) != 0 {
+		t.Errorf("profiles %v kept beyond the size limit", files)
+	}
+}
+
+func TestNewUnknownKind(t *testing.T) {
+	if _, err := profile.New(profile.Config{Dir: t.TempDir(), Kinds: []profile.Kind{"threads"}}); err == nil {
+		t.Error("no error for an unknown kind")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	capturer, err := profile.New(profile.Config{
+		Dir:      dir,
+		Interval: 5 * time.Millisecond,
+		Jitter:   5 * time.Millisecond,
+		Kinds:    []profile.Kind{profile.Heap},
+		OnError: func(err error) {
+			t.Errorf("failed to capture: %v", err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create capturer: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	capturer.Run(ctx)
+
+	if files := names(t, dir); len(files) < 2 {
+		t.Errorf("captured %v, expected several profiles", files)
+	}
+}
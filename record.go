@@ -0,0 +1,137 @@
+package jitter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// timelineMagic identifies the timeline format and its version
+const timelineMagic = "JTL1"
+
+// Event is an event fired by a ticker
+type Event struct {
+	At     time.Time     // Time the event fired at
+	Jitter time.Duration // Difference between the time since the previous event and the interval
+}
+
+// Timeline is the recorded sequence of events of a ticker
+type Timeline struct {
+	Origin time.Time // Time the ticker was started
+	Events []Event   // Events in the order they fired
+}
+
+// Recorder writes the events of a ticker to a writer in a compact binary format, which can be read back with ReadTimeline
+// The format starts with a header holding the start time of the ticker, followed by a pair of varints per event:
+// the nanoseconds elapsed since the previous event and the jitter in nanoseconds
+// Every event results in a single small write, so wrap slow writers in a bufio.Writer and flush it after stopping the ticker
+type Recorder struct {
+	mu      sync.Mutex                      // Protects everything below
+	w       io.Writer                       // Writer the timeline is written to
+	started bool                            // Whether the header was written
+	prev    time.Time                       // Time of the previous event, or the origin before the first one
+	buf     [2 * binary.MaxVarintLen64]byte // Buffer for encoding a record
+	err     error                           // First error returned by the writer
+}
+
+// NewRecorder returns a new recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		w: w,
+	}
+}
+
+// Err returns the first error that occurred while writing, writes are skipped after an error
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+// begin writes the header with the given origin, unless it was already written when the ticker was reset
+func (r *Recorder) begin(origin time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		return
+	}
+
+	r.started = true
+	r.prev = origin
+
+	n := copy(r.buf[:], timelineMagic)
+	n += binary.PutVarint(r.buf[n:], origin.UnixNano())
+	r.write(n)
+}
+
+// record writes an event
+func (r *Recorder) record(at time.Time, jitter time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := binary.PutVarint(r.buf[:], int64(at.Sub(r.prev)))
+	n += binary.PutVarint(r.buf[n:], int64(jitter))
+	r.prev = at
+	r.write(n)
+}
+
+// write writes the first n bytes of the buffer, r.mu must be held
+func (r *Recorder) write(n int) {
+	if r.err != nil {
+		return
+	}
+
+	_, r.err = r.w.Write(r.buf[:n])
+}
+
+// ReadTimeline reads a timeline written by a Recorder
+func ReadTimeline(r io.Reader) (*Timeline, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(timelineMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("failed to read timeline header: %w", err)
+	}
+
+	if string(magic) != timelineMagic {
+		return nil, fmt.Errorf("invalid timeline header: %q", magic)
+	}
+
+	origin, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timeline origin: %w", err)
+	}
+
+	timeline := &Timeline{
+		Origin: time.Unix(0, origin),
+	}
+
+	prev := timeline.Origin
+	for {
+		elapsed, err := binary.ReadVarint(br)
+		if errors.Is(err, io.EOF) {
+			return timeline, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event %d: %w", len(timeline.Events), err)
+		}
+
+		jitter, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jitter of event %d: %w", len(timeline.Events), err)
+		}
+
+		prev = prev.Add(time.Duration(elapsed))
+		timeline.Events = append(timeline.Events, Event{
+			At:     prev,
+			Jitter: time.Duration(jitter),
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package jitter_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+// collect advances the clock in steps of at most step, returning the ticks received up to the end
+func collect(clock *jittertest.Clock, ticker *jitter.Ticker, step time.Duration, end time.Time) []time.Time {
+	var ticks []time.Time
+	for clock.Now().Before(end) {
+		clock.Advance(step)
+
+		select {
+		case tick := <-ticker.C:
+			ticks = append(ticks, tick)
+		default:
+		}
+	}
+
+	return ticks
+}
+
+func TestRecordReplay(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := jittertest.NewClock(start)
+
+	var buf bytes.Buffer
+	recorder := jitter.NewRecorder(&buf)
+	ticker := jitter.NewTicker(time.Second, 500*time.Millisecond,
+		jitter.WithClock(clock),
+		jitter.WithSource(jitter.NewSource(1)),
+		jitter.WithRecorder(recorder),
+	)
+
+	recorded := collect(clock, ticker, 100*time.Millisecond, start.Add(10*time.Second))
+	ticker.Stop()
+
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+
+	timeline, err := jitter.ReadTimeline(&buf)
+	if err != nil {
+		t.Fatalf("failed to read timeline: %v", err)
+	}
+
+	if !timeline.Origin.Equal(start) {
+		t.Errorf("timeline origin %v, expected %v", timeline.Origin, start)
+	}
+
+	if len(timeline.Events) != len(recorded) {
+		t.Fatalf("timeline has %d events, expected %d", len(timeline.Events), len(recorded))
+	}
+
+	for i, event := range timeline.Events {
+		if !event.At.Equal(recorded[i]) {
+			t.Errorf("event %d at %v, expected %v", i, event.At, recorded[i])
+		}
+
+		if event.Jitter < 0 || event.Jitter >= 500*time.Millisecond {
+			t.Errorf("event %d has jitter %v, out of range", i, event.Jitter)
+		}
+	}
+
+	// Replay the timeline on a clock with a different start
+	replayStart := time.Unix(5000, 0)
+	replayClock := jittertest.NewClock(replayStart)
+	replay := jitter.NewReplayTicker(timeline, jitter.WithClock(replayClock))
+	defer replay.Stop()
+
+	replayed := collect(replayClock, replay, 100*time.Millisecond, replayStart.Add(20*time.Second))
+	if len(replayed) != len(recorded) {
+		t.Fatalf("replayed %d events, expected %d", len(replayed), len(recorded))
+	}
+
+	for i := range replayed {
+		if replayed[i].Sub(replayStart) != recorded[i].Sub(start) {
+			t.Errorf("replayed event %d at %v, expected %v", i, replayed[i].Sub(replayStart), recorded[i].Sub(start))
+		}
+	}
+
+	if next := replay.Next(); !next.IsZero() {
+		t.Errorf("Next() = %v after the timeline ended, expected zero time", next)
+	}
+}
+
+func TestReadTimelineInvalid(t *testing.T) {
+	if _, err := jitter.ReadTimeline(strings.NewReader("nope")); err == nil {
+		t.Error("no error for an invalid header")
+	}
+
+	if _, err := jitter.ReadTimeline(strings.NewReader("JTL1")); err == nil {
+		t.Error("no error for a missing origin")
+	}
+}
@@ -0,0 +1,161 @@
+package jitter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Recycler keeps a number of long-running workers going and replaces each of them after a jittered max lifetime,
+// so workers started together, such as right after a deploy, aren't all recycled at the same moment
+// A replacement is started before the worker it replaces is stopped, and at most a limited number of workers
+// are being replaced at any time, counting until the stopped worker has returned
+type Recycler struct {
+	lifetime time.Duration                       // Lifetime of every worker
+	jitter   time.Duration                       // Max jitter added to the lifetime
+	run      func(ctx context.Context, slot int) // Function running a worker until its context is cancelled
+	clock    Clock                               // Clock the lifetimes are measured on
+	source   *Source                             // Source of randomness for generating jitter
+	backoff  Backoff                             // Backoff before restarting workers that returned early
+
+	replacing chan struct{}      // Semaphore limiting the concurrent replacements
+	ctx       context.Context    // Context of all workers, cancelled when stopping
+	cancel    context.CancelFunc // Stops the recycler
+	wg        sync.WaitGroup     // Slots being managed
+}
+
+// NewRecycler starts the given number of workers and returns a recycler replacing them after the lifetime plus a jitter
+// run is called with the index of the slot the worker fills, and has to return once its context is cancelled
+// A worker that returns before its lifetime is up is started again after a backoff, which defaults to one starting
+// at a second and growing to a minute, and can be set with WithBackoff
+func NewRecycler(workers int, lifetime time.Duration, jitter time.Duration, concurrency int, run func(ctx context.Context, slot int), opts ...Option) *Recycler {
+	checkInterval("NewRecycler", lifetime, jitter)
+
+	if workers <= 0 {
+		panic(fmt.Errorf("non-positive workers for NewRecycler: %d", workers))
+	}
+
+	if concurrency <= 0 {
+		panic(fmt.Errorf("non-positive concurrency for NewRecycler: %d", concurrency))
+	}
+
+	o := newOptions(opts)
+
+	backoff := Backoff{
+		Base:   time.Second,
+		Max:    time.Minute,
+		Jitter: 0.5,
+	}
+
+	if o.backoff != nil {
+		backoff = *o.backoff
+	}
+
+	if backoff.Source == nil {
+		backoff.Source = o.source
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Recycler{
+		lifetime: lifetime,
+		jitter:   jitter,
+		run:      run,
+		clock:    o.clock,
+		source:   o.source,
+		backoff:  backoff,
+
+		replacing: make(chan struct{}, concurrency),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	r.wg.Add(workers)
+	for slot := 0; slot < workers; slot++ {
+		go r.manage(slot)
+	}
+
+	return r
+}
+
+// Stop stops all workers and waits for them to return, stopping a stopped recycler has no effect
+func (r *Recycler) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// worker is a running worker
+type worker struct {
+	cancel context.CancelFunc // Stops the worker
+	done   chan struct{}      // Closed once the worker has returned
+}
+
+// start starts a worker in the slot
+func (r *Recycler) start(slot int) worker {
+	ctx, cancel := context.WithCancel(r.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.run(ctx, slot)
+	}()
+
+	return worker{
+		cancel: cancel,
+		done:   done,
+	}
+}
+
+// stop stops the worker and waits for it to return
+func (w worker) stop() {
+	w.cancel()
+	<-w.done
+}
+
+// manage keeps a worker running in the slot until the recycler is stopped
+func (r *Recycler) manage(slot int) {
+	defer r.wg.Done()
+
+	current := r.start(slot)
+	failures := 0
+
+	for {
+		expired := make(chan struct{})
+		timer := r.clock.AfterFunc(r.source.Duration(r.lifetime, r.jitter), func() {
+			close(expired)
+		})
+
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			current.stop()
+			return
+		case <-current.done:
+			// The worker returned early, restart it without counting it as a replacement as there's nothing to stop
+			timer.Stop()
+			current.cancel()
+
+			delay := r.backoff.Delay(failures)
+			failures++
+			if !sleep(r.ctx, r.clock, delay) {
+				return
+			}
+
+			current = r.start(slot)
+		case <-expired:
+			failures = 0
+
+			select {
+			case <-r.ctx.Done():
+				current.stop()
+				return
+			case r.replacing <- struct{}{}:
+			}
+
+			replacement := r.start(slot)
+			current.stop()
+			current = replacement
+
+			<-r.replacing
+		}
+	}
+}
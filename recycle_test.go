@@ -0,0 +1,143 @@
+package jitter_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+// workers tracks the workers started by a recycler
+type workers struct {
+	mu       sync.Mutex
+	started  map[int]int // Workers started per slot
+	running  int         // Workers running, including stopping ones
+	stopping int         // Workers stopping
+	maximum  int         // Most workers stopping at once
+	drain    time.Duration
+}
+
+func (w *workers) run(ctx context.Context, slot int) {
+	w.mu.Lock()
+	w.started[slot]++
+	w.running++
+	w.mu.Unlock()
+
+	<-ctx.Done()
+
+	w.mu.Lock()
+	w.stopping++
+	if w.stopping > w.maximum {
+		w.maximum = w.stopping
+	}
+	w.mu.Unlock()
+
+	time.Sleep(w.drain) // Finish the work in progress
+
+	w.mu.Lock()
+	w.running--
+	w.stopping--
+	w.mu.Unlock()
+}
+
+// waitTimers waits until n timers are pending on the clock
+func waitTimers(t *testing.T, clock *jittertest.Clock, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for clock.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d pending timers, got %d", n, clock.Pending())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRecycler(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+	w := &workers{started: make(map[int]int), drain: 10 * time.Millisecond}
+
+	recycler := jitter.NewRecycler(5, time.Hour, 10*time.Minute, 2, w.run, jitter.WithClock(clock), jitter.WithSource(jitter.NewSource(1)))
+
+	waitTimers(t, clock, 5)
+	clock.Advance(time.Hour - time.Nanosecond)
+
+	w.mu.Lock()
+	for slot := 0; slot < 5; slot++ {
+		if w.started[slot] != 1 {
+			t.Errorf("slot %d: expected no replacement before the lifetime, got %d workers", slot, w.started[slot])
+		}
+	}
+	w.mu.Unlock()
+
+	clock.Advance(10 * time.Minute)
+	waitTimers(t, clock, 5)
+
+	recycler.Stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for slot := 0; slot < 5; slot++ {
+		if w.started[slot] != 2 {
+			t.Errorf("slot %d: expected a single replacement, got %d workers", slot, w.started[slot])
+		}
+	}
+
+	if w.running != 0 {
+		t.Errorf("expected all workers to return after stopping, %d running", w.running)
+	}
+}
+
+func TestRecyclerConcurrency(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+	w := &workers{started: make(map[int]int), drain: 20 * time.Millisecond}
+
+	recycler := jitter.NewRecycler(5, time.Hour, time.Nanosecond, 2, w.run, jitter.WithClock(clock))
+	defer recycler.Stop()
+
+	waitTimers(t, clock, 5)
+	clock.Advance(time.Hour)
+	waitTimers(t, clock, 5)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maximum != 2 {
+		t.Errorf("expected 2 workers stopping at most, got %d", w.maximum)
+	}
+}
+
+func TestRecyclerRestartsWorkers(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+
+	var mu sync.Mutex
+	starts := 0
+	run := func(ctx context.Context, slot int) {
+		mu.Lock()
+		starts++
+		mu.Unlock()
+	}
+
+	recycler := jitter.NewRecycler(1, time.Hour, time.Minute, 1, run, jitter.WithClock(clock), jitter.WithBackoff(jitter.Backoff{Base: time.Second}))
+	defer recycler.Stop()
+
+	// The worker returns right away, and is restarted after 1s, 2s and 4s
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		waitTimers(t, clock, 1)
+		clock.Advance(delay)
+	}
+
+	waitTimers(t, clock, 1)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if starts != 4 {
+		t.Errorf("expected 4 starts, got %d", starts)
+	}
+}
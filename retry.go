@@ -0,0 +1,88 @@
+package jitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RetryPolicy is how failures with a class of errors are retried
+// The zero value doesn't retry, which suits errors such as failed authentication that retrying won't fix
+type RetryPolicy struct {
+	Backoff Backoff // Delays before the retries, counting only the failures of this class
+	Retries int     // Max number of retries after failures of this class, zero for none, negative for no limit
+}
+
+// RetryRule applies a policy to the errors it matches
+type RetryRule struct {
+	Match  func(err error) bool // Reports whether the error is of the class, any classifier can be used
+	Policy RetryPolicy          // Policy for the errors of the class
+}
+
+// Retry retries failed operations with a policy depending on the error
+// Rate limits can wait longer than connection resets, and authentication errors need not be retried at all
+type Retry struct {
+	Rules   []RetryRule // Rules checked in order, the first one matching an error applies
+	Default RetryPolicy // Policy for the errors not matching any rule
+}
+
+// ErrorIs returns a matcher for the errors that are target according to errors.Is
+func ErrorIs(target error) func(err error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// ErrorAs returns a matcher for the errors that can be assigned to E according to errors.As
+func ErrorAs[E error]() func(err error) bool {
+	return func(err error) bool {
+		var target E
+		return errors.As(err, &target)
+	}
+}
+
+// Do calls op until it succeeds, the policy of the error doesn't allow another retry or the context is cancelled
+// Every class has its own budget of retries and its own backoff, growing with the failures of that class only
+// It returns the last error of op, wrapped together with the context error when cancelled while waiting
+// WithClock and WithSource apply to the waits, the source is used by the backoffs without one
+func (r Retry) Do(ctx context.Context, op func(ctx context.Context) error, opts ...Option) error {
+	o := newOptions(opts)
+
+	// Failures per rule, the last entry is for the default policy
+	failures := make([]int, len(r.Rules)+1)
+
+	for {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+
+		class, policy := r.classify(err)
+		if policy.Retries >= 0 && failures[class] >= policy.Retries {
+			return err
+		}
+
+		backoff := policy.Backoff
+		if backoff.Source == nil {
+			backoff.Source = o.source
+		}
+
+		delay := backoff.Delay(failures[class])
+		failures[class]++
+
+		if !sleep(ctx, o.clock, delay) {
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}
+}
+
+// classify returns the index and policy of the first rule matching the error, or the default policy after the rules
+func (r Retry) classify(err error) (int, RetryPolicy) {
+	for i, rule := range r.Rules {
+		if rule.Match(err) {
+			return i, rule.Policy
+		}
+	}
+
+	return len(r.Rules), r.Default
+}
@@ -0,0 +1,130 @@
+package jitter_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+type rateLimitError struct{}
+
+func (rateLimitError) Error() string {
+	return "rate limited"
+}
+
+var errAuth = errors.New("unauthorized")
+
+// waitPending waits until a timer is pending on the clock
+func waitPending(t *testing.T, clock *jittertest.Clock) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for clock.Pending() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no pending timer")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRetryClasses(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+
+	retry := jitter.Retry{
+		Rules: []jitter.RetryRule{
+			{Match: jitter.ErrorIs(errAuth)},
+			{Match: jitter.ErrorAs[rateLimitError](), Policy: jitter.RetryPolicy{Backoff: jitter.Backoff{Base: time.Minute}, Retries: 3}},
+			{Match: jitter.ErrorIs(io.ErrUnexpectedEOF), Policy: jitter.RetryPolicy{Backoff: jitter.Backoff{Base: time.Second}, Retries: 5}},
+		},
+	}
+
+	failures := []error{io.ErrUnexpectedEOF, io.ErrUnexpectedEOF, rateLimitError{}, io.ErrUnexpectedEOF, rateLimitError{}, errAuth}
+	calls := 0
+	op := func(ctx context.Context) error {
+		err := failures[calls]
+		calls++
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- retry.Do(context.Background(), op, jitter.WithClock(clock))
+	}()
+
+	// Every class backs off on its own
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, time.Minute, 4 * time.Second, 2 * time.Minute} {
+		waitPending(t, clock)
+		clock.Advance(delay)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errAuth) {
+			t.Errorf("expected the authentication error without retrying, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retry didn't return")
+	}
+
+	if calls != len(failures) {
+		t.Errorf("expected %d calls, got %d", len(failures), calls)
+	}
+
+	if elapsed := clock.Now().Sub(time.Unix(0, 0)); elapsed != 3*time.Minute+7*time.Second {
+		t.Errorf("expected to wait 3m7s, waited %v", elapsed)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	retry := jitter.Retry{
+		Default: jitter.RetryPolicy{Backoff: jitter.Backoff{Base: time.Millisecond, Jitter: 1}, Retries: 2},
+	}
+
+	calls := 0
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return io.ErrUnexpectedEOF
+	})
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) || calls != 3 {
+		t.Errorf("expected the error after 3 calls, got %v after %d", err, calls)
+	}
+
+	calls = 0
+	retry.Default.Retries = -1
+	err = retry.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 10 {
+			return io.ErrUnexpectedEOF
+		}
+
+		return nil
+	})
+
+	if err != nil || calls != 10 {
+		t.Errorf("expected success after 10 calls without a limit, got %v after %d", err, calls)
+	}
+}
+
+func TestRetryCancel(t *testing.T) {
+	retry := jitter.Retry{
+		Default: jitter.RetryPolicy{Backoff: jitter.Backoff{Base: time.Hour}, Retries: -1},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := retry.Do(ctx, func(ctx context.Context) error {
+		return io.ErrUnexpectedEOF
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected both the context error and the last error, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Package sdnotify sends notifications to systemd, including watchdog keepalives on a jittered interval
+// See sd_notify(3) for the protocol
+package sdnotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// Notifications understood by systemd
+const (
+	Ready     = "READY=1"     // The service finished starting up
+	Stopping  = "STOPPING=1"  // The service is beginning to shut down
+	Reloading = "RELOADING=1" // The service is reloading its configuration
+	Watchdog  = "WATCHDOG=1"  // Keepalive for the watchdog
+)
+
+var (
+	// ErrNoSocket is returned when the service isn't expected to send notifications
+	ErrNoSocket = errors.New("NOTIFY_SOCKET not set")
+
+	// ErrNoWatchdog is returned when the watchdog isn't enabled for the service
+	ErrNoWatchdog = errors.New("watchdog not enabled")
+)
+
+// Notifier sends notifications to the service manager over a unix datagram socket
+type Notifier struct {
+	conn *net.UnixConn
+}
+
+// New returns a notifier for the socket at the given path, a leading @ denotes an abstract socket
+func New(socket string) (*Notifier, error) {
+	addr := &net.UnixAddr{
+		Name: socket,
+		Net:  "unixgram",
+	}
+
+	if strings.HasPrefix(socket, "@") {
+		addr.Name = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to notify socket: %w", err)
+	}
+
+	return &Notifier{
+		conn: conn,
+	}, nil
+}
+
+// FromEnv returns a notifier for the socket in NOTIFY_SOCKET, or ErrNoSocket if it's not set
+func FromEnv() (*Notifier, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil, ErrNoSocket
+	}
+
+	return New(socket)
+}
+
+// Notify sends the given state, which may consist of several newline separated assignments
+func (n *Notifier) Notify(state string) error {
+	if _, err := n.conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to notify: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the connection to the socket
+func (n *Notifier) Close() error {
+	return n.conn.Close()
+}
+
+// WatchdogTimeout returns the watchdog timeout from WATCHDOG_USEC, or ErrNoWatchdog if the watchdog isn't enabled for this process
+func WatchdogTimeout() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, ErrNoWatchdog
+	}
+
+	// The watchdog is meant for another process, e.g. the parent of this one
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, ErrNoWatchdog
+	}
+
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC: %q", usec)
+	}
+
+	return time.Duration(n) * time.Microsecond, nil
+}
+
+// RunWatchdog sends a keepalive right away and then on a jittered interval until the context is cancelled
+// The keepalives are sent between a quarter and half of the timeout apart, so one can be lost or delayed without tripping the watchdog
+// The options are passed to the ticker
+func (n *Notifier) RunWatchdog(ctx context.Context, timeout time.Duration, opts ...jitter.Option) error {
+	if timeout/4 <= 0 {
+		return fmt.Errorf("watchdog timeout too short: %v", timeout)
+	}
+
+	if err := n.Notify(Watchdog); err != nil {
+		return err
+	}
+
+	ticker := jitter.NewTicker(timeout/4, timeout/4, opts...)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := n.Notify(Watchdog); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package sdnotify_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter/sdnotify"
+)
+
+// listen creates a notify socket in a temporary directory and points NOTIFY_SOCKET at it
+func listen(t *testing.T) *net.UnixConn {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	return conn
+}
+
+// receive reads the next notification from the socket
+func receive(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to receive notification: %v", err)
+	}
+
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	conn := listen(t)
+
+	notifier, err := sdnotify.FromEnv()
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	defer notifier.Close()
+
+	for _, state := range []string{sdnotify.Ready, sdnotify.Stopping} {
+		if err := notifier.Notify(state); err != nil {
+			t.Fatalf("failed to notify: %v", err)
+		}
+
+		if got := receive(t, conn); got != state {
+			t.Errorf("received %q, expected %q", got, state)
+		}
+	}
+}
+
+func TestFromEnvNoSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	if _, err := sdnotify.FromEnv(); !errors.Is(err, sdnotify.ErrNoSocket) {
+		t.Errorf("got error %v, expected %v", err, sdnotify.ErrNoSocket)
+	}
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	if _, err := sdnotify.WatchdogTimeout(); !errors.Is(err, sdnotify.ErrNoWatchdog) {
+		t.Errorf("got error %v without WATCHDOG_USEC, expected %v", err, sdnotify.ErrNoWatchdog)
+	}
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if timeout, err := sdnotify.WatchdogTimeout(); err != nil || timeout != 30*time.Second {
+		t.Errorf("WatchdogTimeout() = %v, %v, expected 30s", timeout, err)
+	}
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if _, err := sdnotify.WatchdogTimeout(); !errors.Is(err, sdnotify.ErrNoWatchdog) {
+		t.Errorf("got error %v for another process, expected %v", err, sdnotify.ErrNoWatchdog)
+	}
+
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "soon")
+	if _, err := sdnotify.WatchdogTimeout(); err == nil {
+		t.Error("no error for an invalid WATCHDOG_USEC")
+	}
+}
+
+func TestRunWatchdog(t *testing.T) {
+	conn := listen(t)
+
+	notifier, err := sdnotify.FromEnv()
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	defer notifier.Close()
+
+	timeout := 40 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- notifier.RunWatchdog(ctx, timeout)
+	}()
+
+	last := time.Now()
+	for i := 0; i < 5; i++ {
+		if got := receive(t, conn); got != sdnotify.Watchdog {
+			t.Fatalf("received %q, expected %q", got, sdnotify.Watchdog)
+		}
+
+		if gap := time.Since(last); gap >= timeout {
+			t.Errorf("keepalive %d came %v after the previous one, past the timeout", i, gap)
+		}
+
+		last = time.Now()
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("RunWatchdog returned %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+// node is a simulated client, it's also the skewed jitter.Clock its ticker runs on
+type node struct {
+	sim    *simulation
+	ticker *jitter.Ticker
+	offset time.Duration // Offset of the node's clock from the virtual time
+	drift  float64       // Rate at which the node's clock drifts from the virtual time
+}
+
+// Now returns the time as seen by the node
+func (n *node) Now() time.Time {
+	elapsed := n.sim.clock.Now().Sub(n.sim.cfg.Start)
+	return n.sim.cfg.Start.Add(n.offset + elapsed + time.Duration(float64(elapsed)*n.drift))
+}
+
+// AfterFunc calls f once d has elapsed on the node's clock, then handles any tick the ticker emitted
+func (n *node) AfterFunc(d time.Duration, f func()) jitter.Timer {
+	return n.sim.clock.AfterFunc(n.virtual(d), func() {
+		f()
+		n.poll()
+	})
+}
+
+// virtual converts a duration on the node's clock to virtual time
+func (n *node) virtual(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / (1 + n.drift))
+}
+
+// poll starts a call if the ticker emitted a tick
+func (n *node) poll() {
+	select {
+	case <-n.ticker.C:
+		n.sim.result.Calls++
+		n.send(0)
+	default:
+	}
+}
+
+// send sends the given attempt of a call to the server
+func (n *node) send(attempt int) {
+	n.sim.after(n.sim.latency(), func() {
+		n.sim.handle(attempt > 0, func(ok bool) {
+			n.sim.after(n.sim.latency(), func() {
+				n.receive(attempt, ok)
+			})
+		})
+	})
+}
+
+// receive handles the response to an attempt, retrying after a backoff if it failed
+func (n *node) receive(attempt int, ok bool) {
+	if ok {
+		n.sim.result.Succeeded++
+		return
+	}
+
+	if attempt+1 >= n.sim.cfg.MaxAttempts {
+		n.sim.result.Failed++
+		return
+	}
+
+	n.sim.after(n.virtual(n.sim.cfg.Backoff.Delay(attempt)), func() {
+		n.send(attempt + 1)
+	})
+}
@@ -0,0 +1,250 @@
+// Package sim simulates a cluster of nodes calling a shared server on jittered schedules, entirely in virtual time
+// Each node runs a jitter.Ticker on its own skewed clock, started when that clock reaches a multiple of the interval
+// like a client scheduled on the wall clock, and retries failed calls with a jitter.Backoff,
+// while the server rejects calls beyond its capacity or during outages. This makes it possible to study how
+// synchronized clients cause retry storms and how long the server takes to recover from an outage
+package sim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+// Config describes a simulated cluster
+type Config struct {
+	Start time.Time // Virtual time the simulation starts at
+	Seed  uint64    // Seed of all randomness, so runs with the same config are identical
+
+	Nodes         int             // Number of nodes calling the server
+	Interval      time.Duration   // Interval of the node tickers
+	Jitter        time.Duration   // Max jitter of the node tickers
+	TickerOptions []jitter.Option // Additional options for the node tickers
+	Skew          time.Duration   // Max clock offset of a node, sampled in [-Skew, Skew), which shifts when its ticker starts
+	Drift         float64         // Max clock drift rate of a node, sampled in [-Drift, Drift), e.g. 0.001 for 1ms per second
+
+	Latency       time.Duration // One-way network delay between a node and the server
+	LatencyJitter time.Duration // Max jitter added to the network delay of every message
+
+	Backoff     jitter.Backoff // Backoff between attempts of a call, in node time
+	MaxAttempts int            // Max attempts per call, 1 for no retries
+
+	Capacity    int           // Max number of requests the server processes at once, more are rejected
+	ServiceTime time.Duration // Time the server takes to process a request
+	Outages     []Outage      // Periods in which the server rejects every request
+
+	Bucket time.Duration // Width of the time buckets the results are aggregated in
+}
+
+// Outage is a period in which the server rejects every request
+type Outage struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Result is the outcome of a simulation
+type Result struct {
+	Calls     int // Number of calls started by the node tickers
+	Succeeded int // Number of calls that eventually succeeded
+	Failed    int // Number of calls that ran out of attempts
+	Attempts  int // Number of requests that reached the server, including retries
+	Rejected  int // Number of requests rejected by the server
+	PeakLoad  int // Max number of requests the server processed at once
+
+	Buckets []Bucket // Server side statistics over time
+}
+
+// Bucket holds the server side statistics of a period of the simulation
+type Bucket struct {
+	Start    time.Time // Start of the period
+	Attempts int       // Requests that arrived at the server
+	Retries  int       // Requests that arrived at the server and were retries
+	Rejected int       // Requests the server rejected
+	Served   int       // Requests the server processed
+}
+
+// Recovery returns how long after t the server first went a full bucket without rejecting a request, or -1 if it never did
+func (r *Result) Recovery(t time.Time) time.Duration {
+	for _, bucket := range r.Buckets {
+		if bucket.Start.Before(t) {
+			continue
+		}
+
+		if bucket.Rejected == 0 {
+			return bucket.Start.Sub(t)
+		}
+	}
+
+	return -1
+}
+
+// Run simulates the cluster for the given duration of virtual time
+func Run(cfg Config, d time.Duration) (*Result, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	s := &simulation{
+		cfg:    cfg,
+		clock:  jittertest.NewClock(cfg.Start),
+		source: jitter.NewSource(cfg.Seed),
+		result: &Result{},
+	}
+
+	s.cfg.Backoff.Source = s.source
+
+	buckets := int((d + cfg.Bucket - 1) / cfg.Bucket)
+	s.result.Buckets = make([]Bucket, buckets)
+	for i := range s.result.Buckets {
+		s.result.Buckets[i].Start = cfg.Start.Add(time.Duration(i) * cfg.Bucket)
+	}
+
+	for i := 0; i < cfg.Nodes; i++ {
+		s.startNode()
+	}
+
+	s.clock.Advance(d)
+
+	for _, ticker := range s.tickers {
+		ticker.Stop()
+	}
+
+	return s.result, nil
+}
+
+func (cfg Config) validate() error {
+	if cfg.Nodes <= 0 {
+		return fmt.Errorf("non-positive number of nodes: %d", cfg.Nodes)
+	}
+
+	if cfg.Interval <= 0 || cfg.Jitter <= 0 {
+		return fmt.Errorf("non-positive interval or jitter: %v, %v", cfg.Interval, cfg.Jitter)
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		return fmt.Errorf("non-positive max attempts: %d", cfg.MaxAttempts)
+	}
+
+	if cfg.Capacity <= 0 {
+		return fmt.Errorf("non-positive capacity: %d", cfg.Capacity)
+	}
+
+	if cfg.Bucket <= 0 {
+		return fmt.Errorf("non-positive bucket width: %v", cfg.Bucket)
+	}
+
+	if cfg.Skew < 0 {
+		return fmt.Errorf("negative skew: %v", cfg.Skew)
+	}
+
+	if cfg.Drift <= -1 || cfg.Drift >= 1 {
+		return fmt.Errorf("drift out of range: %v", cfg.Drift)
+	}
+
+	return nil
+}
+
+// simulation is the state of a running simulation
+type simulation struct {
+	cfg    Config
+	clock  *jittertest.Clock // Virtual time shared by the cluster
+	source *jitter.Source    // Source of all randomness
+	result *Result
+
+	tickers []*jitter.Ticker // Tickers of the nodes that started so far
+	active  int              // Number of requests the server is processing
+}
+
+// after calls f once d of virtual time has elapsed
+func (s *simulation) after(d time.Duration, f func()) {
+	s.clock.AfterFunc(d, f)
+}
+
+// latency returns the delay of a message between a node and the server
+func (s *simulation) latency() time.Duration {
+	return s.source.Duration(s.cfg.Latency, s.cfg.LatencyJitter)
+}
+
+// bucket returns the bucket for the current time, or nil if it's past the end of the simulation
+func (s *simulation) bucket() *Bucket {
+	i := int(s.clock.Now().Sub(s.cfg.Start) / s.cfg.Bucket)
+	if i >= len(s.result.Buckets) {
+		return nil
+	}
+
+	return &s.result.Buckets[i]
+}
+
+// startNode creates a node with a skewed clock, its ticker starts once the node's clock reaches a multiple of the interval
+func (s *simulation) startNode() {
+	n := &node{
+		sim:    s,
+		offset: s.source.Around(0, s.cfg.Skew),
+		drift:  s.cfg.Drift * (2*s.source.Float64() - 1),
+	}
+
+	now := n.Now()
+	start := now.Truncate(s.cfg.Interval)
+	if start.Before(now) {
+		start = start.Add(s.cfg.Interval)
+	}
+
+	s.after(n.virtual(start.Sub(now)), func() {
+		opts := append([]jitter.Option{
+			jitter.WithClock(n),
+			jitter.WithSource(s.source),
+		}, s.cfg.TickerOptions...)
+		n.ticker = jitter.NewTicker(s.cfg.Interval, s.cfg.Jitter, opts...)
+		s.tickers = append(s.tickers, n.ticker)
+	})
+}
+
+// handle processes a request arriving at the server and calls respond with whether it was served
+func (s *simulation) handle(retry bool, respond func(ok bool)) {
+	s.result.Attempts++
+	bucket := s.bucket()
+	if bucket != nil {
+		bucket.Attempts++
+		if retry {
+			bucket.Retries++
+		}
+	}
+
+	if s.active >= s.cfg.Capacity || s.inOutage() {
+		s.result.Rejected++
+		if bucket != nil {
+			bucket.Rejected++
+		}
+
+		respond(false)
+		return
+	}
+
+	s.active++
+	if s.active > s.result.PeakLoad {
+		s.result.PeakLoad = s.active
+	}
+
+	s.after(s.cfg.ServiceTime, func() {
+		s.active--
+		if bucket := s.bucket(); bucket != nil {
+			bucket.Served++
+		}
+
+		respond(true)
+	})
+}
+
+// inOutage reports whether the server is in an outage
+func (s *simulation) inOutage() bool {
+	now := s.clock.Now()
+	for _, outage := range s.cfg.Outages {
+		if !now.Before(outage.Start) && now.Before(outage.End) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,131 @@
+package sim_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/sim"
+)
+
+func config() sim.Config {
+	return sim.Config{
+		Start: time.Unix(0, 0),
+		Seed:  1,
+
+		Nodes:    100,
+		Interval: 10 * time.Second,
+		Jitter:   time.Millisecond,
+		Skew:     time.Millisecond,
+		Drift:    0.0001,
+
+		Latency:       5 * time.Millisecond,
+		LatencyJitter: 5 * time.Millisecond,
+
+		Backoff: jitter.Backoff{
+			Base:   100 * time.Millisecond,
+			Max:    5 * time.Second,
+			Jitter: 1,
+		},
+		MaxAttempts: 5,
+
+		Capacity:    10,
+		ServiceTime: 100 * time.Millisecond,
+
+		Bucket: 10 * time.Second,
+	}
+}
+
+func TestRunDeterministic(t *testing.T) {
+	a, err := sim.Run(config(), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	b, err := sim.Run(config(), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("runs with the same seed differ: %+v != %+v", a, b)
+	}
+
+	if a.Calls == 0 || a.Succeeded+a.Failed > a.Calls {
+		t.Errorf("inconsistent result: %+v", a)
+	}
+}
+
+func TestRunJitterSpreadsLoad(t *testing.T) {
+	synchronized, err := sim.Run(config(), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	cfg := config()
+	cfg.Jitter = cfg.Interval
+	spread, err := sim.Run(cfg, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	if synchronized.Rejected <= spread.Rejected {
+		t.Errorf("%d rejections with little jitter, not more than %d with full jitter", synchronized.Rejected, spread.Rejected)
+	}
+
+	if synchronized.PeakLoad != 10 {
+		t.Errorf("peak load %d for synchronized nodes, expected the capacity", synchronized.PeakLoad)
+	}
+}
+
+func TestRunSkewSpreadsLoad(t *testing.T) {
+	synchronized, err := sim.Run(config(), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	cfg := config()
+	cfg.Skew = cfg.Interval / 2
+	skewed, err := sim.Run(cfg, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	if synchronized.Rejected <= skewed.Rejected {
+		t.Errorf("%d rejections with little skew, not more than %d with a large skew", synchronized.Rejected, skewed.Rejected)
+	}
+
+	if skewed.PeakLoad >= synchronized.PeakLoad {
+		t.Errorf("peak load %d with a large skew, not less than %d with little skew", skewed.PeakLoad, synchronized.PeakLoad)
+	}
+}
+
+func TestRunRecovery(t *testing.T) {
+	cfg := config()
+	cfg.Jitter = cfg.Interval
+	end := cfg.Start.Add(2 * time.Minute)
+	cfg.Outages = []sim.Outage{{Start: cfg.Start.Add(time.Minute), End: end}}
+
+	result, err := sim.Run(cfg, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	if result.Recovery(end) < 0 {
+		t.Errorf("server never recovered: %+v", result.Buckets)
+	}
+
+	if result.Recovery(cfg.Start.Add(time.Minute)) < time.Minute {
+		t.Errorf("recovered during the outage: %+v", result.Buckets)
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	cfg := config()
+	cfg.Nodes = 0
+
+	if _, err := sim.Run(cfg, time.Minute); err == nil {
+		t.Error("no error for an invalid config")
+	}
+}
@@ -0,0 +1,86 @@
+package jitter
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Source is a source of randomness for jitter that is safe for concurrent use, so a single one can be shared by tickers and helpers
+// A seeded Source is guarded by a lock and produces a reproducible sequence of values, which is useful for tests and simulations
+// A nil *Source is valid and uses the lock-free per-thread generators of the runtime instead
+type Source struct {
+	mu     sync.Mutex // Protects random
+	random *rand.Rand // Seeded generator
+}
+
+// NewSource returns a new source seeded with the given seed
+func NewSource(seed uint64) *Source {
+	return &Source{
+		random: rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// Int64N returns a random number in [0, n), it panics if n is not positive
+func (s *Source) Int64N(n int64) int64 {
+	if s == nil {
+		return rand.Int64N(n)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.random.Int64N(n)
+}
+
+// uint64N returns a random number in [0, n), it panics if n is zero
+func (s *Source) uint64N(n uint64) uint64 {
+	if s == nil {
+		return rand.Uint64N(n)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.random.Uint64N(n)
+}
+
+// Float64 returns a random number in [0.0, 1.0)
+func (s *Source) Float64() float64 {
+	if s == nil {
+		return rand.Float64()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.random.Float64()
+}
+
+// Duration returns d with a random jitter in [0, j) added
+// If j is not positive, d is returned unchanged
+func (s *Source) Duration(d, j time.Duration) time.Duration {
+	if j <= 0 {
+		return d
+	}
+
+	return d + time.Duration(s.Int64N(int64(j)))
+}
+
+// Around returns d with a random jitter in [-j, j) added
+// If j is not positive, d is returned unchanged
+func (s *Source) Around(d, j time.Duration) time.Duration {
+	if j <= 0 {
+		return d
+	}
+
+	// 2*j overflows durations for the largest jitters, but always fits unsigned, and the offset from -j wraps back into range
+	offset := s.uint64N(2 * uint64(j))
+	return d + time.Duration(offset-uint64(j))
+}
+
+// Fraction returns d with a random jitter in [0, f*d) added, e.g. a fraction of 0.1 adds up to 10%
+// If the resulting jitter is not positive, d is returned unchanged
+func (s *Source) Fraction(d time.Duration, f float64) time.Duration {
+	return s.Duration(d, time.Duration(float64(d)*f))
+}
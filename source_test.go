@@ -0,0 +1,117 @@
+package jitter_test
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+func TestSourceSeed(t *testing.T) {
+	a := jitter.NewSource(42)
+	b := jitter.NewSource(42)
+
+	for i := 0; i < 100; i++ {
+		if x, y := a.Duration(time.Second, time.Second), b.Duration(time.Second, time.Second); x != y {
+			t.Fatalf("sources with the same seed diverged at %d: %v != %v", i, x, y)
+		}
+	}
+}
+
+func TestSourceRanges(t *testing.T) {
+	for name, source := range map[string]*jitter.Source{
+		"seeded": jitter.NewSource(1),
+		"nil":    nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := time.Second
+			j := 100 * time.Millisecond
+
+			for i := 0; i < 1000; i++ {
+				if got := source.Duration(d, j); got < d || got >= d+j {
+					t.Fatalf("Duration(%v, %v) = %v, out of range", d, j, got)
+				}
+
+				if got := source.Around(d, j); got < d-j || got >= d+j {
+					t.Fatalf("Around(%v, %v) = %v, out of range", d, j, got)
+				}
+
+				if got := source.Fraction(d, 0.1); got < d || got >= d+d/10 {
+					t.Fatalf("Fraction(%v, 0.1) = %v, out of range", d, got)
+				}
+
+				if got := source.Float64(); got < 0 || got >= 1 {
+					t.Fatalf("Float64() = %v, out of range", got)
+				}
+			}
+		})
+	}
+}
+
+// Run with -race to check that a shared source is safe for concurrent use
+func TestSourceConcurrent(t *testing.T) {
+	source := jitter.NewSource(1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				source.Duration(time.Second, time.Second)
+				source.Float64()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestTickerWithSource(t *testing.T) {
+	source := jitter.NewSource(1)
+
+	tickers := []*jitter.Ticker{
+		jitter.NewTicker(10*time.Millisecond, 10*time.Millisecond, jitter.WithSource(source)),
+		jitter.NewTicker(10*time.Millisecond, 10*time.Millisecond, jitter.WithSource(source)),
+	}
+
+	for _, ticker := range tickers {
+		defer ticker.Stop()
+	}
+
+	for i := 0; i < 5; i++ {
+		for _, ticker := range tickers {
+			<-ticker.C
+		}
+	}
+}
+
+func TestSourceAroundLargeJitter(t *testing.T) {
+	for name, source := range map[string]*jitter.Source{
+		"seeded": jitter.NewSource(1),
+		"nil":    nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, j := range []time.Duration{math.MaxInt64/2 + 1, math.MaxInt64} {
+				below := false
+				above := false
+				for i := 0; i < 100; i++ {
+					got := source.Around(0, j)
+					if got < -j || got >= j {
+						t.Fatalf("Around(0, %v) = %v, out of range", j, got)
+					}
+
+					below = below || got < 0
+					above = above || got > 0
+				}
+
+				if !below || !above {
+					t.Errorf("Around(0, %v) didn't spread to both sides", j)
+				}
+			}
+		})
+	}
+}
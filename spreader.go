@@ -0,0 +1,205 @@
+package jitter
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Spreader probes a changing set of targets once per interval each, spreading the probes evenly over the interval
+// Every target is probed at a stable offset within the interval derived from a hash of its name, plus a small jitter,
+// so the probes of a target keep a constant phase across restarts while different targets don't line up
+// A probe that's still running when the next one is due causes that one to be skipped
+type Spreader struct {
+	interval time.Duration                            // Interval at which every target is probed
+	jitter   time.Duration                            // Max jitter added to the offset of every probe
+	probe    func(ctx context.Context, target string) // Function called to probe a target
+	clock    Clock                                    // Clock the spreader runs on
+	source   *Source                                  // Source of randomness for generating jitter
+
+	mu      sync.Mutex               // Protects everything below
+	targets map[string]*spreadTarget // Targets being probed
+	stopped bool                     // Whether the spreader is stopped
+}
+
+// spreadTarget is the state of a target of a Spreader
+type spreadTarget struct {
+	name    string             // Name of the target
+	slot    time.Time          // Start of the slot of the next probe, the probe runs within jitter after it
+	timer   Timer              // Timer firing the next probe
+	running bool               // Whether a probe is running
+	cancel  context.CancelFunc // Cancels the running probe
+	removed bool               // Whether the target was removed
+}
+
+// NewSpreader returns a new spreader with no targets, probe is called with a context that's cancelled when the target is removed,
+// the spreader is stopped or the next probe is due
+func NewSpreader(interval time.Duration, jitter time.Duration, probe func(ctx context.Context, target string), opts ...Option) *Spreader {
+	checkInterval("NewSpreader", interval, jitter)
+
+	if jitter > interval {
+		panic(fmt.Errorf("jitter greater than the interval for NewSpreader: %d", int(jitter)))
+	}
+
+	o := newOptions(opts)
+
+	return &Spreader{
+		interval: interval,
+		jitter:   jitter,
+		probe:    probe,
+		clock:    o.clock,
+		source:   o.source,
+
+		targets: make(map[string]*spreadTarget),
+	}
+}
+
+// Offset returns the stable offset of the target within the interval, relative to the Unix epoch
+func (s *Spreader) Offset(target string) time.Duration {
+	h := fnv.New64a()
+	h.Write([]byte(target))
+
+	return time.Duration(h.Sum64() % uint64(s.interval))
+}
+
+// Add starts probing the target, adding a target that's already being probed has no effect
+func (s *Spreader) Add(target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(target)
+}
+
+// add starts probing the target, s.mu must be held
+func (s *Spreader) add(target string) {
+	if s.stopped || s.targets[target] != nil {
+		return
+	}
+
+	// Find the first slot from now that has the offset of the target
+	now := s.clock.Now()
+	phase := time.Duration(now.UnixNano() % int64(s.interval))
+	wait := (s.Offset(target) - phase + s.interval) % s.interval
+
+	t := &spreadTarget{
+		name: target,
+		slot: now.Add(wait),
+	}
+
+	s.targets[target] = t
+	s.arm(t)
+}
+
+// Remove stops probing the target and cancels its running probe
+func (s *Spreader) Remove(target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(target)
+}
+
+// remove stops probing the target, s.mu must be held
+func (s *Spreader) remove(target string) {
+	t := s.targets[target]
+	if t == nil {
+		return
+	}
+
+	delete(s.targets, target)
+	t.removed = true
+	t.timer.Stop()
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
+// Set replaces the targets with the given ones, the targets that remain keep their schedule
+func (s *Spreader) Set(targets []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keep := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		keep[target] = true
+	}
+
+	for target := range s.targets {
+		if !keep[target] {
+			s.remove(target)
+		}
+	}
+
+	for _, target := range targets {
+		s.add(target)
+	}
+}
+
+// Targets returns the sorted names of the targets being probed
+func (s *Spreader) Targets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targets := make([]string, 0, len(s.targets))
+	for target := range s.targets {
+		targets = append(targets, target)
+	}
+
+	sort.Strings(targets)
+
+	return targets
+}
+
+// Stop stops probing all targets and cancels the running probes, it returns immediately
+func (s *Spreader) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for target := range s.targets {
+		s.remove(target)
+	}
+}
+
+// arm starts a timer for the next probe of the target, s.mu must be held
+func (s *Spreader) arm(t *spreadTarget) {
+	at := s.source.Duration(0, s.jitter)
+	t.timer = s.clock.AfterFunc(t.slot.Add(at).Sub(s.clock.Now()), func() {
+		s.fire(t)
+	})
+}
+
+// fire runs a probe of the target, unless the previous one is still running
+func (s *Spreader) fire(t *spreadTarget) {
+	s.mu.Lock()
+	if t.removed {
+		s.mu.Unlock()
+		return
+	}
+
+	t.slot = t.slot.Add(s.interval)
+	s.arm(t)
+
+	if t.running {
+		s.mu.Unlock()
+		return
+	}
+
+	// Cancel the probe when the next one is due, on the clock of the spreader
+	ctx, cancel := context.WithCancel(context.Background())
+	timeout := s.clock.AfterFunc(t.slot.Sub(s.clock.Now()), cancel)
+	t.running = true
+	t.cancel = cancel
+	s.mu.Unlock()
+
+	s.probe(ctx, t.name)
+	timeout.Stop()
+	cancel()
+
+	s.mu.Lock()
+	t.running = false
+	t.cancel = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,88 @@
+package jitter_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+	"github.com/gerifield/jitter/jittertest"
+)
+
+// probes records the times targets were probed at
+type probes struct {
+	mu    sync.Mutex
+	clock jitter.Clock
+	at    map[string][]time.Time
+}
+
+func (p *probes) probe(ctx context.Context, target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.at[target] = append(p.at[target], p.clock.Now())
+}
+
+func TestSpreader(t *testing.T) {
+	interval := 10 * time.Second
+	jitterMax := 100 * time.Millisecond
+
+	clock := jittertest.NewClock(time.Unix(1000, 0))
+	p := &probes{clock: clock, at: make(map[string][]time.Time)}
+	spreader := jitter.NewSpreader(interval, jitterMax, p.probe, jitter.WithClock(clock))
+	defer spreader.Stop()
+
+	spreader.Set([]string{"a:9100", "b:9100", "c:9100"})
+	clock.Advance(time.Minute)
+
+	spreader.Remove("b:9100")
+	spreader.Add("d:9100")
+	clock.Advance(time.Minute)
+
+	if targets := spreader.Targets(); !reflect.DeepEqual(targets, []string{"a:9100", "c:9100", "d:9100"}) {
+		t.Errorf("Targets() = %v", targets)
+	}
+
+	for target, count := range map[string]int{"a:9100": 12, "b:9100": 6, "c:9100": 12, "d:9100": 6} {
+		at := p.at[target]
+		if len(at) < count-1 || len(at) > count {
+			t.Errorf("%s probed %d times, expected %d", target, len(at), count)
+		}
+
+		// Every probe is within the jitter after the offset of the target
+		offset := spreader.Offset(target)
+		for _, probe := range at {
+			phase := time.Duration(probe.UnixNano() % int64(interval))
+			if late := (phase - offset + interval) % interval; late >= jitterMax {
+				t.Errorf("%s probed %v after its offset", target, late)
+			}
+		}
+	}
+}
+
+func TestSpreaderCancelsRemoved(t *testing.T) {
+	clock := jittertest.NewClock(time.Unix(0, 0))
+
+	started := make(chan struct{})
+	done := make(chan error)
+	probe := func(ctx context.Context, target string) {
+		close(started)
+		<-ctx.Done()
+		done <- ctx.Err()
+	}
+
+	spreader := jitter.NewSpreader(time.Second, time.Millisecond, probe, jitter.WithClock(clock))
+	defer spreader.Stop()
+
+	spreader.Add("target")
+	go clock.Advance(time.Second)
+
+	<-started
+	spreader.Remove("target")
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("probe context error %v, expected %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,89 @@
+package jitter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Watch polls fetch on a jittered interval and emits a value on the returned channel whenever it differs from the last one emitted
+// The first value is fetched and emitted right away. After a failed fetch the interval is replaced by a backoff,
+// which defaults to one starting at the interval and growing to 32 times it, and can be set with WithBackoff
+// The channel is closed once the context is cancelled, emitting blocks until the receiver takes the value
+func Watch[T any](ctx context.Context, interval time.Duration, jitter time.Duration, fetch func(ctx context.Context) (T, error), equal func(a, b T) bool, opts ...Option) <-chan T {
+	checkInterval("Watch", interval, jitter)
+
+	if equal == nil {
+		panic(fmt.Errorf("nil equal for Watch"))
+	}
+
+	o := newOptions(opts)
+
+	backoff := Backoff{
+		Base:   interval,
+		Max:    32 * interval,
+		Jitter: 0.5,
+	}
+
+	if o.backoff != nil {
+		backoff = *o.backoff
+	}
+
+	if backoff.Source == nil {
+		backoff.Source = o.source
+	}
+
+	c := make(chan T)
+	go func() {
+		defer close(c)
+
+		var last T
+		emitted := false
+		failures := 0
+
+		for {
+			value, err := fetch(ctx)
+
+			delay := o.source.Duration(interval, jitter)
+			if err != nil {
+				delay = backoff.Delay(failures)
+				failures++
+			} else {
+				failures = 0
+
+				if !emitted || !equal(last, value) {
+					select {
+					case <-ctx.Done():
+						return
+					case c <- value:
+					}
+
+					last = value
+					emitted = true
+				}
+			}
+
+			if !sleep(ctx, o.clock, delay) {
+				return
+			}
+		}
+	}()
+
+	return c
+}
+
+// sleep waits for d on the clock and returns true, or returns false if the context is cancelled first
+func sleep(ctx context.Context, clock Clock, d time.Duration) bool {
+	done := make(chan struct{})
+	timer := clock.AfterFunc(d, func() {
+		close(done)
+	})
+
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return false
+	case <-done:
+		return true
+	}
+}
@@ -0,0 +1,92 @@
+package jitter_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gerifield/jitter"
+)
+
+func TestWatch(t *testing.T) {
+	type result struct {
+		value int
+		err   error
+	}
+
+	results := []result{
+		{value: 1},
+		{value: 1},
+		{value: 2},
+		{err: errors.New("unavailable")},
+		{err: errors.New("unavailable")},
+		{value: 2},
+		{value: 3},
+	}
+
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) (int, error) {
+		i := int(calls.Add(1)) - 1
+		if i >= len(results) {
+			return 3, nil
+		}
+
+		return results[i].value, results[i].err
+	}
+
+	equal := func(a, b int) bool {
+		return a == b
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	values := jitter.Watch(ctx, time.Millisecond, time.Millisecond, fetch, equal, jitter.WithBackoff(jitter.Backoff{Base: time.Millisecond}))
+
+	for _, expected := range []int{1, 2, 3} {
+		select {
+		case value := <-values:
+			if value != expected {
+				t.Fatalf("got %d, expected %d", value, expected)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no value %d", expected)
+		}
+	}
+
+	cancel()
+
+	select {
+	case value, ok := <-values:
+		if ok {
+			t.Errorf("got %d after cancelling, expected the channel to be closed", value)
+		}
+	case <-time.After(time.Second):
+		t.Error("channel not closed after cancelling")
+	}
+}
+
+func TestWatchBacksOff(t *testing.T) {
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		return 0, errors.New("unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	backoff := jitter.Backoff{Base: 10 * time.Millisecond}
+	values := jitter.Watch(ctx, time.Millisecond, time.Millisecond, fetch, func(a, b int) bool { return a == b }, jitter.WithBackoff(backoff))
+
+	for range values {
+		t.Error("value emitted for a failing fetch")
+	}
+
+	// Waiting 10ms, 20ms, 40ms and 80ms fits at most 4 fetches in 100ms, instead of about 60 at the interval
+	if n := calls.Load(); n > 5 {
+		t.Errorf("fetched %d times, expected the backoff to slow down fetching", n)
+	}
+}